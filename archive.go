@@ -0,0 +1,93 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// archiveConcurrency is the number of objects moved concurrently by ArchiveOlderThan.
+const archiveConcurrency = 10
+
+// ArchiveOlderThan moves objects under srcPrefix last modified before the cutoff to archivePrefix,
+// keeping the rest of the key. Each object is copied and then deleted.
+// It returns the number of moved objects; failed moves are joined into the returned error.
+func (s *Client) ArchiveOlderThan(ctx context.Context, bucketName string, srcPrefix string, archivePrefix string, cutoff time.Time) (int, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return 0, err
+	}
+
+	if archivePrefix == "" {
+		return 0, NewValidationError("archive prefix is empty")
+	}
+
+	if srcPrefix == archivePrefix {
+		return 0, NewValidationError("archive prefix equals source prefix")
+	}
+
+	if cutoff.IsZero() {
+		return 0, NewValidationError("cutoff is empty")
+	}
+
+	ctx = s.startRetryBudget(ctx)
+
+	var objects []types.Object
+
+	err := s.walkObjects(ctx, bucketName, srcPrefix, func(object types.Object) error {
+		key := aws.ToString(object.Key)
+		if strings.HasPrefix(key, archivePrefix) || !aws.ToTime(object.LastModified).Before(cutoff) {
+			return nil
+		}
+
+		objects = append(objects, object)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var moved atomic.Int64
+
+	errs := make([]error, len(objects))
+
+	runPool(ctx, len(objects), archiveConcurrency, func(i int) {
+		key := aws.ToString(objects[i].Key)
+		archiveKey := archivePrefix + strings.TrimPrefix(key, srcPrefix)
+
+		errs[i] = s.moveObject(ctx, bucketName, key, archiveKey, aws.ToInt64(objects[i].Size))
+		if errs[i] == nil {
+			moved.Add(1)
+		}
+	})
+
+	if err := ctx.Err(); err != nil {
+		return int(moved.Load()), err
+	}
+
+	return int(moved.Load()), errors.Join(errs...)
+}
+
+// moveObject copies the object to the destination key within the bucket and deletes the source.
+func (s *Client) moveObject(ctx context.Context, bucketName string, key string, dstKey string, size int64) error {
+	_, err := s.copyObject(ctx, bucketName, key, bucketName, dstKey, size, copyOptions{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return NewS3Error("unable to delete object", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package s3utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_ArchiveOlderThan(t *testing.T) {
+	cutoff := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	fake := newFakeS3()
+	fake.put("bucket", "raw/old_1.json", []byte("1"))
+	fake.put("bucket", "raw/nested/old_2.json", []byte("2"))
+	fake.put("bucket", "raw/new.json", []byte("3"))
+	fake.setLastModified("bucket", "raw/old_1.json", cutoff.Add(-48*time.Hour))
+	fake.setLastModified("bucket", "raw/nested/old_2.json", cutoff.Add(-time.Second))
+	fake.setLastModified("bucket", "raw/new.json", cutoff.Add(time.Hour))
+
+	client := newTestClient(t, fake)
+
+	moved, err := client.ArchiveOlderThan(context.Background(), "bucket", "raw/", "archive/", cutoff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if moved != 2 {
+		t.Errorf("actual moved `%v` \n expected `2`", moved)
+	}
+
+	for _, key := range []string{"archive/old_1.json", "archive/nested/old_2.json", "raw/new.json"} {
+		if _, ok := fake.get("bucket", key); !ok {
+			t.Errorf("object `%v` must exist", key)
+		}
+	}
+
+	for _, key := range []string{"raw/old_1.json", "raw/nested/old_2.json", "archive/new.json"} {
+		if _, ok := fake.get("bucket", key); ok {
+			t.Errorf("object `%v` must not exist", key)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package s3utils
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+// AssumeRoleOption configures the role assumed with WithAssumeRole.
+type AssumeRoleOption func(*assumeRoleOptions)
+
+type assumeRoleOptions struct {
+	roleARN         string
+	sessionName     string
+	externalID      string
+	sessionDuration time.Duration
+}
+
+// WithAssumeRole makes the client assume the IAM role before accessing S3, e.g. a role of another account.
+// The role is assumed with the credentials resolved by the default chain, WithProfile or WithCredentials,
+// and its temporary credentials are refreshed automatically before they expire.
+//
+// The trust policy of the role must allow the calling principal to perform sts:AssumeRole, e.g.
+//
+//	{
+//	  "Effect": "Allow",
+//	  "Principal": {"AWS": "arn:aws:iam::111122223333:role/pipeline"},
+//	  "Action": "sts:AssumeRole",
+//	  "Condition": {"StringEquals": {"sts:ExternalId": "external-id"}}
+//	}
+//
+// where the condition is needed only when the role requires an external ID, see WithExternalID.
+func WithAssumeRole(roleARN string, sessionName string, opts ...AssumeRoleOption) Option {
+	return func(o *clientOptions) {
+		o.assumeRole = &assumeRoleOptions{
+			roleARN:     roleARN,
+			sessionName: sessionName,
+		}
+
+		for _, opt := range opts {
+			opt(o.assumeRole)
+		}
+	}
+}
+
+// WithExternalID sets the external ID required by the trust policy of the assumed role.
+func WithExternalID(externalID string) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.externalID = externalID
+	}
+}
+
+// WithSessionDuration sets the lifetime of the assumed role credentials. It defaults to 15 minutes.
+func WithSessionDuration(duration time.Duration) AssumeRoleOption {
+	return func(o *assumeRoleOptions) {
+		o.sessionDuration = duration
+	}
+}
+
+func (o *assumeRoleOptions) validate() error {
+	if o.roleARN == "" {
+		return NewValidationError("role ARN is empty")
+	}
+
+	if o.sessionName == "" {
+		return NewValidationError("session name is empty")
+	}
+
+	if o.sessionDuration < 0 {
+		return NewValidationError("session duration must not be negative")
+	}
+
+	return nil
+}
+
+// newAssumeRoleProvider returns the cached credentials of the role assumed through the STS client.
+func newAssumeRoleProvider(client stscreds.AssumeRoleAPIClient, o *assumeRoleOptions) aws.CredentialsProvider {
+	provider := stscreds.NewAssumeRoleProvider(client, o.roleARN, func(ao *stscreds.AssumeRoleOptions) {
+		ao.RoleSessionName = o.sessionName
+
+		if o.externalID != "" {
+			ao.ExternalID = aws.String(o.externalID)
+		}
+
+		if o.sessionDuration > 0 {
+			ao.Duration = o.sessionDuration
+		}
+	})
+
+	return aws.NewCredentialsCache(provider)
+}
@@ -0,0 +1,92 @@
+package s3utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+type fakeSTS struct {
+	inputs []*sts.AssumeRoleInput
+}
+
+func (f *fakeSTS) AssumeRole(_ context.Context, params *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	f.inputs = append(f.inputs, params)
+
+	return &sts.AssumeRoleOutput{
+		Credentials: &types.Credentials{
+			AccessKeyId:     aws.String("access-key"),
+			SecretAccessKey: aws.String("secret-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func TestWithAssumeRole(t *testing.T) {
+	o, err := newClientOptions([]Option{
+		WithAssumeRole("arn:aws:iam::111122223333:role/reader", "pipeline", WithExternalID("external-id"), WithSessionDuration(time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fake := &fakeSTS{}
+	provider := newAssumeRoleProvider(fake, o.assumeRole)
+
+	// The second retrieval is served from the cache.
+	for range 2 {
+		creds, err := provider.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if creds.AccessKeyID != "access-key" || !creds.CanExpire {
+			t.Errorf("actual `%+v` \n expected refreshable credentials", creds)
+		}
+	}
+
+	if len(fake.inputs) != 1 {
+		t.Fatalf("actual `%v` AssumeRole calls \n expected `1`", len(fake.inputs))
+	}
+
+	input := fake.inputs[0]
+	if aws.ToString(input.RoleArn) != "arn:aws:iam::111122223333:role/reader" ||
+		aws.ToString(input.RoleSessionName) != "pipeline" ||
+		aws.ToString(input.ExternalId) != "external-id" ||
+		aws.ToInt32(input.DurationSeconds) != 3600 {
+		t.Errorf("actual `%+v` \n expected the configured role", input)
+	}
+}
+
+func TestWithAssumeRole_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{
+			name: "empty_role_arn",
+			opt:  WithAssumeRole("", "pipeline"),
+		},
+		{
+			name: "empty_session_name",
+			opt:  WithAssumeRole("arn:aws:iam::111122223333:role/reader", ""),
+		},
+		{
+			name: "negative_session_duration",
+			opt:  WithAssumeRole("arn:aws:iam::111122223333:role/reader", "pipeline", WithSessionDuration(-time.Minute)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newClientOptions([]Option{tt.opt}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package s3utils
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxDeleteObjects is the maximum number of keys accepted by a single DeleteObjects request.
+const maxDeleteObjects = 1000
+
+// objectsExistConcurrency is the number of HeadObject requests sent in parallel by ObjectsExist.
+const objectsExistConcurrency = 16
+
+// UploadJob describes a single file upload performed by UploadFiles.
+type UploadJob struct {
+	Directory        string
+	FilePath         string
+	ExternalFilename string
+}
+
+// UploadResult holds the outcome of a single UploadJob.
+type UploadResult struct {
+	Job  UploadJob
+	Info UploadInfo
+	Err  error
+	// Skipped reports that UploadWithManifest skipped the job because it was uploaded by a previous run.
+	Skipped bool
+}
+
+// UploadFiles uploads files concurrently using a pool of concurrency workers. The options apply to every file.
+// Per-file errors are stored in the returned results, which are in the same order as jobs.
+// The returned error is non-nil only if the context was cancelled; jobs that were not dispatched
+// before cancellation have the context error set in their result.
+func (s *Client) UploadFiles(ctx context.Context, bucketName string, jobs []UploadJob, concurrency int, opts ...UploadOption) ([]UploadResult, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return nil, err
+	}
+
+	if concurrency < 1 {
+		return nil, NewValidationError("concurrency must be positive")
+	}
+
+	if _, err := newUploadOptions(opts); err != nil {
+		return nil, err
+	}
+
+	ctx = s.startRetryBudget(ctx)
+
+	results := make([]UploadResult, len(jobs))
+	for i, job := range jobs {
+		results[i].Job = job
+	}
+
+	dispatched := runPool(ctx, len(jobs), concurrency, func(i int) {
+		job := jobs[i]
+		results[i].Info, results[i].Err = s.UploadFileBase(ctx, bucketName, job.Directory, job.FilePath, job.ExternalFilename, opts...)
+	})
+
+	if err := ctx.Err(); err != nil {
+		for i := dispatched; i < len(jobs); i++ {
+			results[i].Err = err
+		}
+
+		return results, err
+	}
+
+	return results, nil
+}
+
+// ObjectsExist reports for every key whether an object with exactly the key exists, sending HeadObject requests
+// with bounded concurrency. The returned map is keyed by the given keys. The first error other than a missing object
+// stops the remaining requests and is returned.
+func (s *Client) ObjectsExist(ctx context.Context, bucketName string, keys []string) (map[string]bool, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, NewValidationError("key is empty")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.startRetryBudget(ctx))
+	defer cancel()
+
+	exists := make([]bool, len(keys))
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	runPool(ctx, len(keys), objectsExistConcurrency, func(i int) {
+		ok, err := s.IsObjectExists(ctx, bucketName, keys[i])
+		if err != nil {
+			errOnce.Do(func() {
+				firstErr = err
+				cancel()
+			})
+
+			return
+		}
+
+		exists[i] = ok
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		result[key] = exists[i]
+	}
+
+	return result, nil
+}
+
+// DeleteObjects deletes objects by keys, issuing one request per 1000 keys.
+// Keys that S3 failed to delete are reported by a MultiDeleteError wrapped in the returned error.
+func (s *Client) DeleteObjects(ctx context.Context, bucketName string, keys []string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return NewValidationError("key is empty")
+		}
+	}
+
+	_, err := s.deleteKeys(s.startRetryBudget(ctx), bucketName, keys, true)
+
+	return err
+}
+
+// DeleteObjectsVerbose deletes objects by keys like DeleteObjects, but requests the verbose response,
+// in which S3 confirms every deleted key, and returns the confirmed keys.
+// Keys that don't exist are confirmed too, as S3 treats their deletion as successful.
+func (s *Client) DeleteObjectsVerbose(ctx context.Context, bucketName string, keys []string) ([]string, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, NewValidationError("key is empty")
+		}
+	}
+
+	return s.deleteKeys(s.startRetryBudget(ctx), bucketName, keys, false)
+}
+
+// deleteKeys deletes keys in batches of 1000, sending up to the delete concurrency of the client in parallel,
+// and collects the per-key errors reported by S3 across the batches. It returns the deleted keys,
+// also along with an error: the keys confirmed by S3 unless quiet, otherwise the keys of the sent batches
+// that S3 didn't report as failed. A failed request stops the batches not sent yet.
+func (s *Client) deleteKeys(ctx context.Context, bucketName string, keys []string, quiet bool) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := chunkStrings(keys, maxDeleteObjects)
+	batches := make([]deleteBatch, len(chunks))
+
+	var (
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	dispatched := runPool(ctx, len(chunks), s.options.deleteConcurrency(), func(i int) {
+		batches[i] = s.deleteBatch(ctx, bucketName, chunks[i], quiet)
+		if batches[i].err != nil {
+			errOnce.Do(func() {
+				firstErr = batches[i].err
+				cancel()
+			})
+		}
+	})
+
+	var (
+		deleted []string
+		errs    []DeleteObjectError
+	)
+
+	for _, batch := range batches {
+		deleted = append(deleted, batch.deleted...)
+		errs = append(errs, batch.errs...)
+	}
+
+	if firstErr != nil {
+		return deleted, firstErr
+	}
+
+	if dispatched < len(chunks) {
+		return deleted, NewS3Error("unable to delete objects", ctx.Err())
+	}
+
+	if len(errs) > 0 {
+		return deleted, NewS3Error("unable to delete some objects", NewMultiDeleteError(errs))
+	}
+
+	return deleted, nil
+}
+
+// deleteBatch is the outcome of a single DeleteObjects request of deleteKeys.
+type deleteBatch struct {
+	deleted []string
+	errs    []DeleteObjectError
+	err     error
+}
+
+// deleteBatch deletes up to 1000 keys with a single DeleteObjects request.
+func (s *Client) deleteBatch(ctx context.Context, bucketName string, keys []string, quiet bool) deleteBatch {
+	deleteObjects := make([]types.ObjectIdentifier, 0, len(keys))
+	for _, key := range keys {
+		deleteObjects = append(deleteObjects, types.ObjectIdentifier{
+			Key: aws.String(key),
+		})
+	}
+
+	deleteResp, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &types.Delete{
+			Objects: deleteObjects,
+			Quiet:   aws.Bool(quiet),
+		},
+	})
+	if err != nil {
+		return deleteBatch{err: NewS3Error("unable to delete objects", err)}
+	}
+
+	var batch deleteBatch
+
+	for _, deletedObject := range deleteResp.Deleted {
+		batch.deleted = append(batch.deleted, aws.ToString(deletedObject.Key))
+	}
+
+	failed := make(map[string]bool, len(deleteResp.Errors))
+
+	for _, deleteErr := range deleteResp.Errors {
+		failed[aws.ToString(deleteErr.Key)] = true
+		batch.errs = append(batch.errs, DeleteObjectError{
+			Key:     aws.ToString(deleteErr.Key),
+			Code:    aws.ToString(deleteErr.Code),
+			Message: aws.ToString(deleteErr.Message),
+		})
+	}
+
+	if quiet {
+		for _, key := range keys {
+			if !failed[key] {
+				batch.deleted = append(batch.deleted, key)
+			}
+		}
+	}
+
+	return batch
+}
+
+func chunkStrings(items []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		chunks = append(chunks, items[start:min(start+size, len(items))])
+	}
+
+	return chunks
+}
+
+// runPool calls fn for indexes 0..n-1 using concurrency workers and waits for them to finish.
+// It stops dispatching when ctx is cancelled and returns the number of dispatched indexes.
+func runPool(ctx context.Context, n int, concurrency int, fn func(i int)) int {
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(concurrency, n) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	dispatched := 0
+
+dispatch:
+	for dispatched < n {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case indexes <- dispatched:
+			dispatched++
+		}
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return dispatched
+}
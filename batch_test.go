@@ -0,0 +1,300 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func Test_chunkStrings(t *testing.T) {
+	keys := func(n int) []string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = fmt.Sprintf("key_%d", i)
+		}
+
+		return items
+	}
+
+	tests := []struct {
+		name  string
+		items []string
+		size  int
+		want  []int
+	}{
+		{
+			name:  "empty",
+			items: nil,
+			size:  1000,
+			want:  []int{},
+		},
+		{
+			name:  "single_chunk",
+			items: keys(10),
+			size:  1000,
+			want:  []int{10},
+		},
+		{
+			name:  "exact_chunks",
+			items: keys(2000),
+			size:  1000,
+			want:  []int{1000, 1000},
+		},
+		{
+			name:  "last_chunk_shorter",
+			items: keys(2500),
+			size:  1000,
+			want:  []int{1000, 1000, 500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkStrings(tt.items, tt.size)
+			if len(got) != len(tt.want) {
+				t.Fatalf("actual %d chunks \n expected %d chunks", len(got), len(tt.want))
+			}
+
+			for i, chunk := range got {
+				if len(chunk) != tt.want[i] {
+					t.Errorf("chunk %d: actual size `%v` \n expected `%v`", i, len(chunk), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_UploadFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobs      []UploadJob
+		cancelled bool
+		wantKeys  []string
+		wantErrs  []bool
+		wantErr   error
+	}{
+		{
+			name: "all_uploaded",
+			jobs: []UploadJob{
+				{Directory: "raw", FilePath: writeTestFile(t, "a.json", `{"a":1}`), ExternalFilename: "a.json"},
+				{Directory: "raw", FilePath: writeTestFile(t, "b.json", `{"b":1}`), ExternalFilename: "b.json"},
+			},
+			wantKeys: []string{"raw/a.json", "raw/b.json"},
+			wantErrs: []bool{false, false},
+		},
+		{
+			name: "job_error",
+			jobs: []UploadJob{
+				{Directory: "raw", FilePath: writeTestFile(t, "c.json", `{"c":1}`), ExternalFilename: "c.json"},
+				{Directory: "raw", FilePath: "missing.json", ExternalFilename: "missing.json"},
+				{Directory: "raw", FilePath: writeTestFile(t, "d.json", `{"d":1}`), ExternalFilename: "d.json"},
+			},
+			wantKeys: []string{"raw/c.json", "raw/d.json"},
+			wantErrs: []bool{false, true, false},
+		},
+		{
+			name: "cancelled",
+			jobs: []UploadJob{
+				{Directory: "raw", FilePath: writeTestFile(t, "e.json", `{"e":1}`), ExternalFilename: "e.json"},
+				{Directory: "raw", FilePath: writeTestFile(t, "f.json", `{"f":1}`), ExternalFilename: "f.json"},
+				{Directory: "raw", FilePath: writeTestFile(t, "g.json", `{"g":1}`), ExternalFilename: "g.json"},
+			},
+			cancelled: true,
+			wantErrs:  []bool{true, true, true},
+			wantErr:   context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancelled {
+				cancel()
+			}
+
+			results, err := client.UploadFiles(ctx, "bucket", tt.jobs, 2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+
+			if len(results) != len(tt.jobs) {
+				t.Fatalf("actual %d results \n expected %d", len(results), len(tt.jobs))
+			}
+
+			for i, result := range results {
+				if result.Job != tt.jobs[i] {
+					t.Errorf("result %d: actual job `%v` \n expected `%v`", i, result.Job, tt.jobs[i])
+				}
+
+				if (result.Err != nil) != tt.wantErrs[i] {
+					t.Errorf("result %d: actual error `%v` \n expected error `%v`", i, result.Err, tt.wantErrs[i])
+				}
+
+				if tt.cancelled && !errors.Is(result.Err, context.Canceled) {
+					t.Errorf("result %d: actual error `%v` \n expected `%v`", i, result.Err, context.Canceled)
+				}
+			}
+
+			for _, key := range tt.wantKeys {
+				if _, ok := fake.get("bucket", key); !ok {
+					t.Errorf("object `%v` was not uploaded", key)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_DeleteFolder_partialFailure(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/a.json", []byte("a"))
+	fake.put("bucket", "raw/b.json", []byte("b"))
+	fake.put("bucket", "raw/c.json", []byte("c"))
+	fake.lockedKeys = map[string]bool{"raw/b.json": true}
+
+	client := newTestClient(t, fake)
+
+	deleted, err := client.DeleteFolder(context.Background(), "bucket", "raw/")
+
+	var multiErr MultiDeleteError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("actual error `%v` \n expected MultiDeleteError", err)
+	}
+
+	if len(multiErr.Errors) != 1 || multiErr.Errors[0].Key != "raw/b.json" || multiErr.Errors[0].Code != "AccessDenied" {
+		t.Errorf("actual `%v` \n expected a single AccessDenied error for `raw/b.json`", multiErr.Errors)
+	}
+
+	for _, key := range []string{"raw/a.json", "raw/c.json"} {
+		if _, ok := fake.get("bucket", key); ok {
+			t.Errorf("object `%v` was not deleted", key)
+		}
+	}
+
+	want := []string{"raw/a.json", "raw/c.json"}
+	if !slices.Equal(deleted, want) {
+		t.Errorf("actual deleted `%v` \n expected `%v`", deleted, want)
+	}
+
+	for _, input := range fake.deleteInputs {
+		if !aws.ToBool(input.Delete.Quiet) {
+			t.Errorf("actual quiet `false` \n expected `true`")
+		}
+	}
+}
+
+func TestClient_DeleteFolder_concurrency(t *testing.T) {
+	tests := []struct {
+		name            string
+		concurrency     int
+		wantMaxInFlight int
+	}{
+		{
+			name:            "default",
+			concurrency:     0,
+			wantMaxInFlight: 1,
+		},
+		{
+			name:            "parallel",
+			concurrency:     4,
+			wantMaxInFlight: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.delay = 20 * time.Millisecond
+
+			for i := range 4 * maxDeleteObjects {
+				fake.put("bucket", fmt.Sprintf("raw/%05d.json", i), []byte("1"))
+			}
+
+			fake.lockedKeys = map[string]bool{"raw/00001.json": true, "raw/03999.json": true}
+
+			client := newTestClient(t, fake, WithDeleteConcurrency(tt.concurrency))
+
+			deleted, err := client.DeleteFolder(context.Background(), "bucket", "raw")
+
+			var multiErr MultiDeleteError
+			if !errors.As(err, &multiErr) || len(multiErr.Errors) != 2 {
+				t.Fatalf("actual error `%v` \n expected MultiDeleteError of the 2 locked keys", err)
+			}
+
+			if len(deleted) != 4*maxDeleteObjects-2 {
+				t.Errorf("actual %d deleted keys \n expected %d", len(deleted), 4*maxDeleteObjects-2)
+			}
+
+			if len(fake.deleteInputs) != 4 {
+				t.Errorf("actual %d requests \n expected 4", len(fake.deleteInputs))
+			}
+
+			if fake.maxDeletesInFlight != tt.wantMaxInFlight {
+				t.Errorf("actual %d parallel requests \n expected %d", fake.maxDeletesInFlight, tt.wantMaxInFlight)
+			}
+		})
+	}
+
+	var validationErr ValidationError
+	if _, err := newClientOptions([]Option{WithDeleteConcurrency(-1)}); !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_DeleteObjectsVerbose(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/a.json", []byte("a"))
+	fake.put("bucket", "raw/b.json", []byte("b"))
+	fake.lockedKeys = map[string]bool{"raw/b.json": true}
+
+	client := newTestClient(t, fake)
+
+	deleted, err := client.DeleteObjectsVerbose(context.Background(), "bucket", []string{"raw/a.json", "raw/b.json", "raw/missing.json"})
+
+	var multiErr MultiDeleteError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("actual error `%v` \n expected MultiDeleteError", err)
+	}
+
+	want := []string{"raw/a.json", "raw/missing.json"}
+	if !slices.Equal(deleted, want) {
+		t.Errorf("actual `%v` \n expected `%v`", deleted, want)
+	}
+}
+
+func TestClient_ObjectsExist(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/a.json", []byte("a"))
+	fake.put("bucket", "raw/b.json", []byte("b"))
+
+	client := newTestClient(t, fake)
+
+	keys := []string{"raw/a.json", "raw/missing.json", "/raw/b.json"}
+
+	got, err := client.ObjectsExist(context.Background(), "bucket", keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]bool{"raw/a.json": true, "raw/missing.json": false, "/raw/b.json": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actual `%v` \n expected `%v`", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.ObjectsExist(ctx, "bucket", keys); !errors.Is(err, context.Canceled) {
+		t.Errorf("actual error `%v` \n expected `%v`", err, context.Canceled)
+	}
+}
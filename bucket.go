@@ -0,0 +1,221 @@
+package s3utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// BucketInfo describes a bucket listed by ListBuckets.
+type BucketInfo struct {
+	Name         string    `json:"name"`
+	CreationDate time.Time `json:"creation_date"`
+}
+
+// ListBuckets returns the buckets owned by the account of the client credentials, paginating through the listing.
+func (s *Client) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	paginator := s3.NewListBucketsPaginator(s.client, &s3.ListBucketsInput{})
+
+	var buckets []BucketInfo
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, NewS3Error("unable to list buckets", err)
+		}
+
+		for _, bucket := range page.Buckets {
+			buckets = append(buckets, BucketInfo{
+				Name:         aws.ToString(bucket.Name),
+				CreationDate: aws.ToTime(bucket.CreationDate),
+			})
+		}
+	}
+
+	return buckets, nil
+}
+
+// BucketExists reports whether the bucket exists and is accessible with the client credentials.
+func (s *Client) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+	if bucketName == "" {
+		return false, NewValidationError("bucket name is empty")
+	}
+
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isBucketNotFound(err) {
+			return false, nil
+		}
+
+		return false, NewS3Error("unable to get bucket info", err)
+	}
+
+	return true, nil
+}
+
+// Ping checks the credentials and the connectivity to S3, e.g. for a readiness probe. It sends a HeadBucket
+// request for the bucket, or a ListBuckets request for a single bucket if bucketName is empty, which credentials
+// restricted to some buckets may not be allowed to send. Rejected credentials result in an error matching
+// ErrAccessDenied, and network failures in an error matching ErrUnreachable.
+func (s *Client) Ping(ctx context.Context, bucketName string) error {
+	var err error
+	if bucketName == "" {
+		_, err = s.client.ListBuckets(ctx, &s3.ListBucketsInput{
+			MaxBuckets: aws.Int32(1),
+		})
+	} else {
+		_, err = s.client.HeadBucket(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(bucketName),
+		})
+	}
+
+	if err != nil {
+		return newPingError("unable to reach s3", err)
+	}
+
+	return nil
+}
+
+// BucketRegion returns the region where the bucket is located, which may differ from the client region.
+// A missing bucket results in an error matching ErrBucketNotFound.
+func (s *Client) BucketRegion(ctx context.Context, bucketName string) (string, error) {
+	if bucketName == "" {
+		return "", NewValidationError("bucket name is empty")
+	}
+
+	locationResp, err := s.client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		if isBucketNotFound(err) {
+			err = fmt.Errorf("%w: %w", ErrBucketNotFound, err)
+		}
+
+		return "", NewS3Error("unable to get bucket location", err)
+	}
+
+	return bucketRegionFromLocation(locationResp.LocationConstraint), nil
+}
+
+// bucketRegionFromLocation converts the location constraint of a bucket to its region.
+// Buckets in us-east-1 have no location constraint, and "EU" is the legacy constraint of eu-west-1.
+func bucketRegionFromLocation(location types.BucketLocationConstraint) string {
+	switch location {
+	case "":
+		return defaultRegion
+	case types.BucketLocationConstraintEu:
+		return "eu-west-1"
+	}
+
+	return string(location)
+}
+
+// EnableBucketLogging enables server access logging of the source bucket,
+// delivering the logs to the target bucket under the target prefix.
+// The target bucket must exist and allow the S3 logging service to write to it.
+func (s *Client) EnableBucketLogging(ctx context.Context, sourceBucket string, targetBucket string, targetPrefix string) error {
+	if sourceBucket == "" {
+		return NewValidationError("source bucket name is empty")
+	}
+
+	if targetBucket == "" {
+		return NewValidationError("target bucket name is empty")
+	}
+
+	exists, err := s.BucketExists(ctx, targetBucket)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return NewS3Error("unable to enable bucket logging", ErrBucketNotFound)
+	}
+
+	_, err = s.client.PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+		Bucket: aws.String(sourceBucket),
+		BucketLoggingStatus: &types.BucketLoggingStatus{
+			LoggingEnabled: &types.LoggingEnabled{
+				TargetBucket: aws.String(targetBucket),
+				TargetPrefix: aws.String(targetPrefix),
+			},
+		},
+	})
+	if err != nil {
+		return NewS3Error("unable to enable bucket logging", err)
+	}
+
+	return nil
+}
+
+// DisableBucketLogging disables server access logging of the bucket.
+func (s *Client) DisableBucketLogging(ctx context.Context, bucketName string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	_, err := s.client.PutBucketLogging(ctx, &s3.PutBucketLoggingInput{
+		Bucket:              aws.String(bucketName),
+		BucketLoggingStatus: &types.BucketLoggingStatus{},
+	})
+	if err != nil {
+		return NewS3Error("unable to disable bucket logging", err)
+	}
+
+	return nil
+}
+
+// validateBucketName checks the bucket name against the S3 naming rules for general purpose buckets,
+// so invalid names are rejected with a descriptive ValidationError instead of an opaque S3 error.
+func validateBucketName(bucketName string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if len(bucketName) < 3 || len(bucketName) > 63 {
+		return NewValidationError("bucket name must be between 3 and 63 characters long: " + bucketName)
+	}
+
+	for _, r := range bucketName {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '.' && r != '-' {
+			return NewValidationError("bucket name must consist of lowercase letters, digits, dots and hyphens: " + bucketName)
+		}
+	}
+
+	if !isAlphanumeric(bucketName[0]) || !isAlphanumeric(bucketName[len(bucketName)-1]) {
+		return NewValidationError("bucket name must begin and end with a letter or digit: " + bucketName)
+	}
+
+	if strings.Contains(bucketName, "..") {
+		return NewValidationError("bucket name must not contain adjacent dots: " + bucketName)
+	}
+
+	if ip := net.ParseIP(bucketName); ip != nil && ip.To4() != nil {
+		return NewValidationError("bucket name must not be formatted as an IP address: " + bucketName)
+	}
+
+	for _, prefix := range []string{"xn--", "sthree-", "amzn-s3-demo-"} {
+		if strings.HasPrefix(bucketName, prefix) {
+			return NewValidationError("bucket name must not start with " + prefix + ": " + bucketName)
+		}
+	}
+
+	for _, suffix := range []string{"-s3alias", "--ol-s3", ".mrap", "--x-s3"} {
+		if strings.HasSuffix(bucketName, suffix) {
+			return NewValidationError("bucket name must not end with " + suffix + ": " + bucketName)
+		}
+	}
+
+	return nil
+}
+
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,416 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestClient_BucketExists(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	if err := client.CreateBucket(context.Background(), "logs"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		bucket string
+		want   bool
+	}{
+		{
+			name:   "existing",
+			bucket: "logs",
+			want:   true,
+		},
+		{
+			name:   "missing",
+			bucket: "missing",
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.BucketExists(context.Background(), tt.bucket)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_BucketLogging(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("logs", "placeholder", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	err := client.EnableBucketLogging(context.Background(), "data", "logs", "access/data/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logging := fake.bucketLogging["data"].LoggingEnabled
+	if logging == nil || aws.ToString(logging.TargetBucket) != "logs" || aws.ToString(logging.TargetPrefix) != "access/data/" {
+		t.Errorf("actual `%+v` \n expected logging to `logs/access/data/`", logging)
+	}
+
+	if err := client.DisableBucketLogging(context.Background(), "data"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fake.bucketLogging["data"].LoggingEnabled != nil {
+		t.Error("expected logging to be disabled")
+	}
+
+	err = client.EnableBucketLogging(context.Background(), "data", "missing", "access/")
+	if !errors.Is(err, ErrBucketNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrBucketNotFound", err)
+	}
+}
+
+func Test_validateBucketName(t *testing.T) {
+	tests := []struct {
+		name       string
+		bucketName string
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			bucketName: "my-bucket.logs-2024",
+			wantErr:    false,
+		},
+		{
+			name:       "min_length",
+			bucketName: "abc",
+			wantErr:    false,
+		},
+		{
+			name:       "max_length",
+			bucketName: strings.Repeat("a", 63),
+			wantErr:    false,
+		},
+		{
+			name:       "empty",
+			bucketName: "",
+			wantErr:    true,
+		},
+		{
+			name:       "too_short",
+			bucketName: "ab",
+			wantErr:    true,
+		},
+		{
+			name:       "too_long",
+			bucketName: strings.Repeat("a", 64),
+			wantErr:    true,
+		},
+		{
+			name:       "uppercase",
+			bucketName: "My-Bucket",
+			wantErr:    true,
+		},
+		{
+			name:       "underscore",
+			bucketName: "my_bucket",
+			wantErr:    true,
+		},
+		{
+			name:       "leading_hyphen",
+			bucketName: "-bucket",
+			wantErr:    true,
+		},
+		{
+			name:       "trailing_dot",
+			bucketName: "bucket.",
+			wantErr:    true,
+		},
+		{
+			name:       "adjacent_dots",
+			bucketName: "my..bucket",
+			wantErr:    true,
+		},
+		{
+			name:       "ip_address",
+			bucketName: "192.168.5.4",
+			wantErr:    true,
+		},
+		{
+			name:       "reserved_prefix",
+			bucketName: "xn--bucket",
+			wantErr:    true,
+		},
+		{
+			name:       "reserved_suffix",
+			bucketName: "bucket-s3alias",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBucketName(tt.bucketName)
+
+			var validationErr ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_CreateBucket_invalidName(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	err := client.CreateBucket(context.Background(), "My_Bucket")
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	if len(fake.createBucketInputs) != 0 {
+		t.Errorf("actual %d requests \n expected none", len(fake.createBucketInputs))
+	}
+}
+
+func TestClient_writeMethods_invalidBucketName(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(client *Client) error
+	}{
+		{
+			name: "copy_object",
+			write: func(client *Client) error {
+				return client.CopyObject(context.Background(), "src", "raw/data.json", "My_Bucket", "raw/data.json")
+			},
+		},
+		{
+			name: "transform_object",
+			write: func(client *Client) error {
+				return client.TransformObject(context.Background(), "src", "raw/data.json", "My_Bucket", "raw/data.json", func(r io.Reader, w io.Writer) error {
+					_, err := io.Copy(w, r)
+
+					return err
+				})
+			},
+		},
+		{
+			name: "sync_to_bucket",
+			write: func(client *Client) error {
+				return client.SyncToBucket(context.Background(), "My_Bucket", t.TempDir(), "backup")
+			},
+		},
+		{
+			name: "archive_older_than",
+			write: func(client *Client) error {
+				_, err := client.ArchiveOlderThan(context.Background(), "My_Bucket", "raw", "archive", time.Now())
+
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("src", "raw/data.json", []byte(`{"a":1}`))
+
+			client := newTestClient(t, fake)
+
+			err := tt.write(client)
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestClient_BucketRegion(t *testing.T) {
+	fake := newFakeS3()
+
+	for _, region := range []string{"us-east-1", "eu-central-1"} {
+		client := newTestClient(t, fake, WithRegion(region))
+
+		if client.Region() != region {
+			t.Errorf("actual region `%v` \n expected `%v`", client.Region(), region)
+		}
+
+		if err := client.CreateBucket(context.Background(), "logs-"+region); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, err := client.BucketRegion(context.Background(), "logs-"+region)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got != region {
+			t.Errorf("actual `%v` \n expected `%v`", got, region)
+		}
+	}
+
+	client := newTestClient(t, fake)
+
+	_, err := client.BucketRegion(context.Background(), "missing")
+	if !errors.Is(err, ErrBucketNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrBucketNotFound", err)
+	}
+
+	if got := bucketRegionFromLocation(types.BucketLocationConstraintEu); got != "eu-west-1" {
+		t.Errorf("actual `%v` \n expected `eu-west-1`", got)
+	}
+}
+
+// unreachableHTTPClient fails every request as if S3 couldn't be reached.
+type unreachableHTTPClient struct{}
+
+func (unreachableHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return nil, errors.New("dial tcp: lookup s3.test: no such host")
+}
+
+func TestClient_Ping(t *testing.T) {
+	const (
+		listBucketsBody = `<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult><Buckets><Bucket><Name>bucket</Name></Bucket></Buckets></ListAllMyBucketsResult>`
+		invalidAccessKeyBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>InvalidAccessKeyId</Code><Message>The AWS Access Key Id you provided does not exist in our records.</Message></Error>`
+		clockSkewBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>RequestTimeTooSkewed</Code><Message>The difference between the request time and the current time is too large.</Message></Error>`
+	)
+
+	tests := []struct {
+		name       string
+		bucketName string
+		httpClient aws.HTTPClient
+		wantErr    bool
+		// wantIs is the sentinel matched by the error, nil if it matches neither ErrAccessDenied nor ErrUnreachable.
+		wantIs error
+	}{
+		{
+			name:       "list_buckets",
+			httpClient: &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusOK, body: listBucketsBody}}},
+		},
+		{
+			name:       "head_bucket",
+			bucketName: "bucket",
+			httpClient: &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusOK}}},
+		},
+		{
+			name:       "access_denied",
+			httpClient: &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusForbidden, body: invalidAccessKeyBody}}},
+			wantErr:    true,
+			wantIs:     ErrAccessDenied,
+		},
+		{
+			name:       "head_bucket_forbidden",
+			bucketName: "bucket",
+			httpClient: &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusForbidden}}},
+			wantErr:    true,
+			wantIs:     ErrAccessDenied,
+		},
+		{
+			name:       "clock_skew",
+			httpClient: &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusForbidden, body: clockSkewBody}}},
+			wantErr:    true,
+		},
+		{
+			name:       "unreachable",
+			bucketName: "bucket",
+			httpClient: unreachableHTTPClient{},
+			wantErr:    true,
+			wantIs:     ErrUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newHTTPTestClient(t, tt.httpClient, aws.NopRetryer{})
+
+			err := client.Ping(context.Background(), tt.bucketName)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			var s3Err S3Error
+			if !errors.As(err, &s3Err) {
+				t.Fatalf("actual error `%v` \n expected S3Error", err)
+			}
+
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("actual error `%v` \n expected matching `%v`", err, tt.wantIs)
+			}
+
+			if tt.wantIs == nil && (errors.Is(err, ErrAccessDenied) || errors.Is(err, ErrUnreachable)) {
+				t.Errorf("actual error `%v` \n expected matching neither ErrAccessDenied nor ErrUnreachable", err)
+			}
+		})
+	}
+}
+
+func TestClient_ListBuckets(t *testing.T) {
+	const (
+		firstPage = `<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult><Buckets><Bucket><Name>logs</Name><CreationDate>2024-03-01T10:00:00.000Z</CreationDate></Bucket></Buckets>
+<ContinuationToken>next</ContinuationToken></ListAllMyBucketsResult>`
+		secondPage = `<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult><Buckets><Bucket><Name>raw</Name><CreationDate>2025-01-15T08:30:00.000Z</CreationDate></Bucket></Buckets>
+</ListAllMyBucketsResult>`
+	)
+
+	httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{
+		{status: http.StatusOK, body: firstPage},
+		{status: http.StatusOK, body: secondPage},
+	}}
+	client := newHTTPTestClient(t, httpClient, nil)
+
+	buckets, err := client.ListBuckets(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []BucketInfo{
+		{Name: "logs", CreationDate: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "raw", CreationDate: time.Date(2025, 1, 15, 8, 30, 0, 0, time.UTC)},
+	}
+	if !slices.Equal(buckets, want) {
+		t.Errorf("actual `%v` \n expected `%v`", buckets, want)
+	}
+
+	if token := httpClient.requests[1].URL.Query().Get("continuation-token"); token != "next" {
+		t.Errorf("actual continuation token `%v` \n expected `next`", token)
+	}
+}
+
+func TestClient_ListBuckets_error(t *testing.T) {
+	httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusForbidden, body: `<Error><Code>AccessDenied</Code></Error>`}}}
+	client := newHTTPTestClient(t, httpClient, aws.NopRetryer{})
+
+	_, err := client.ListBuckets(context.Background())
+
+	var s3Err S3Error
+	if !errors.As(err, &s3Err) {
+		t.Errorf("actual error `%v` \n expected S3Error", err)
+	}
+}
@@ -0,0 +1,101 @@
+package s3utils
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// verifyAttempts is the number of lookups of an uploaded object that is not visible yet.
+	verifyAttempts = 3
+	// verifyDelay is the delay between the lookups of an uploaded object.
+	verifyDelay = 100 * time.Millisecond
+)
+
+// fileMD5 computes the MD5 of the seekable reader and rewinds it to the start.
+func fileMD5(reader io.ReadSeeker) ([]byte, error) {
+	hash := md5.New()
+
+	_, err := io.Copy(hash, reader)
+	if err != nil {
+		return nil, NewSDKError("unable to compute MD5", err)
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, NewSDKError("unable to rewind file", err)
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// verifyETag checks that the ETag returned by S3 matches the MD5 of the uploaded content.
+// ETags of multipart uploads are not a plain MD5 and are not verified.
+func verifyETag(etag string, sum []byte) error {
+	if err := compareETag(etag, sum); err != nil {
+		return NewS3Error("unable to verify uploaded object", err)
+	}
+
+	return nil
+}
+
+// compareETag returns an error matching ErrChecksumMismatch if the ETag differs from the MD5 sum.
+// ETags of multipart uploads are not a plain MD5 and are not compared.
+func compareETag(etag string, sum []byte) error {
+	etag = strings.Trim(etag, `"`)
+	if strings.Contains(etag, "-") {
+		return nil
+	}
+
+	if etag != hex.EncodeToString(sum) {
+		return fmt.Errorf("%w: etag %s, md5 %s", ErrChecksumMismatch, etag, hex.EncodeToString(sum))
+	}
+
+	return nil
+}
+
+// verifyUpload checks that the uploaded object has the expected size and ETag.
+// An empty etag is not compared.
+func (s *Client) verifyUpload(ctx context.Context, bucketName string, key string, size int64, etag string) error {
+	var headResp *s3.HeadObjectOutput
+
+	for attempt := 1; ; attempt++ {
+		var err error
+
+		headResp, err = s.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucketName),
+			Key:    aws.String(key),
+		})
+		if err == nil {
+			break
+		}
+
+		if !isNotFound(err) || attempt == verifyAttempts {
+			return NewS3Error("unable to verify uploaded object", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return NewS3Error("unable to verify uploaded object", ctx.Err())
+		case <-time.After(verifyDelay):
+		}
+	}
+
+	if actual := aws.ToInt64(headResp.ContentLength); actual != size {
+		return NewS3Error("unable to verify uploaded object", fmt.Errorf("%w: size %d, expected %d", ErrVerificationFailed, actual, size))
+	}
+
+	if actual := aws.ToString(headResp.ETag); etag != "" && actual != etag {
+		return NewS3Error("unable to verify uploaded object", fmt.Errorf("%w: etag %s, expected %s", ErrVerificationFailed, actual, etag))
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClient_UploadFileBase_contentMD5(t *testing.T) {
+	// MD5 of "hello": 5d41402abc4b2a76b9719d911017c592.
+	filePath := writeTestFile(t, "hello.txt", "hello")
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "hello.txt", WithContentMD5(), WithETagVerification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := aws.ToString(fake.putInputs[0].ContentMD5); got != "XUFAKrxLKna5cZ2REBfFkg==" {
+		t.Errorf("actual `%v` \n expected `%v`", got, "XUFAKrxLKna5cZ2REBfFkg==")
+	}
+
+	object, _ := fake.get("bucket", "raw/hello.txt")
+	if string(object.body) != "hello" {
+		t.Errorf("actual `%s` \n expected `hello`", object.body)
+	}
+}
+
+func TestClient_UploadReader_contentMD5(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    func() io.Reader
+		opts    []UploadOption
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "seekable_with_length",
+			body:    func() io.Reader { return strings.NewReader("hello") },
+			opts:    []UploadOption{WithContentMD5(), WithContentLength(5)},
+			want:    "XUFAKrxLKna5cZ2REBfFkg==",
+			wantErr: false,
+		},
+		{
+			name:    "unknown_length",
+			body:    func() io.Reader { return strings.NewReader("hello") },
+			opts:    []UploadOption{WithContentMD5()},
+			wantErr: true,
+		},
+		{
+			name:    "not_seekable",
+			body:    func() io.Reader { return io.MultiReader(strings.NewReader("hello")) },
+			opts:    []UploadOption{WithContentMD5(), WithContentLength(5)},
+			wantErr: true,
+		},
+		{
+			name:    "gzip",
+			body:    func() io.Reader { return strings.NewReader("hello") },
+			opts:    []UploadOption{WithContentMD5(), WithContentLength(5), WithGzip()},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			_, err := client.UploadReader(context.Background(), "bucket", "raw", "hello.txt", tt.body(), tt.opts...)
+
+			var validationErr ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if len(fake.putInputs) != 0 {
+					t.Errorf("actual `%d` requests \n expected no request", len(fake.putInputs))
+				}
+
+				return
+			}
+
+			if got := aws.ToString(fake.putInputs[0].ContentMD5); got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_UploadFileBase_etagMismatch(t *testing.T) {
+	filePath := writeTestFile(t, "hello.txt", "hello")
+
+	fake := newFakeS3()
+	fake.putETag = `"00000000000000000000000000000000"`
+
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "hello.txt", WithETagVerification())
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("actual error `%v` \n expected ErrChecksumMismatch", err)
+	}
+}
+
+func TestClient_UploadReader_etagVerification(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadReader(context.Background(), "bucket", "raw", "hello.txt", strings.NewReader("hello"), WithETagVerification())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_UploadFileBase_verifyAfterUpload(t *testing.T) {
+	filePath := writeTestFile(t, "hello.txt", "hello")
+
+	tests := []struct {
+		name          string
+		headMisses    int
+		headSizeDelta int64
+		wantErr       error
+	}{
+		{
+			name: "verified",
+		},
+		{
+			name:       "eventually_visible",
+			headMisses: verifyAttempts - 1,
+		},
+		{
+			name:          "size_mismatch",
+			headSizeDelta: 1,
+			wantErr:       ErrVerificationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.headMisses = tt.headMisses
+			fake.headSizeDelta = tt.headSizeDelta
+
+			client := newTestClient(t, fake)
+
+			_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "hello.txt", VerifyAfterUpload())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_UploadReader_verifyAfterUpload(t *testing.T) {
+	fake := newFakeS3()
+	fake.headSizeDelta = -1
+
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadReader(context.Background(), "bucket", "raw", "hello.txt", strings.NewReader("hello"), VerifyAfterUpload())
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("actual error `%v` \n expected ErrVerificationFailed", err)
+	}
+
+	fake.headSizeDelta = 0
+
+	_, err = client.UploadReader(context.Background(), "bucket", "raw", "hello.txt", strings.NewReader("hello"), VerifyAfterUpload(), WithGzip())
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_verifyETag(t *testing.T) {
+	sum := []byte{0x5d, 0x41, 0x40, 0x2a, 0xbc, 0x4b, 0x2a, 0x76, 0xb9, 0x71, 0x9d, 0x91, 0x10, 0x17, 0xc5, 0x92}
+
+	tests := []struct {
+		name    string
+		etag    string
+		wantErr bool
+	}{
+		{
+			name: "match",
+			etag: `"5d41402abc4b2a76b9719d911017c592"`,
+		},
+		{
+			name:    "mismatch",
+			etag:    `"00000000000000000000000000000000"`,
+			wantErr: true,
+		},
+		{
+			name: "multipart",
+			etag: `"00000000000000000000000000000000-3"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyETag(tt.etag, sum); (err != nil) != tt.wantErr {
+				t.Errorf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
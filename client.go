@@ -2,191 +2,426 @@ package s3utils
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"io"
+	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
+// S3API is the subset of the S3 client API used by the package.
+// It is implemented by *s3.Client and can be replaced with a mock in tests.
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+	GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+	CreateBucket(ctx context.Context, params *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	RestoreObject(ctx context.Context, params *s3.RestoreObjectInput, optFns ...func(*s3.Options)) (*s3.RestoreObjectOutput, error)
+	PutObjectRetention(ctx context.Context, params *s3.PutObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error)
+	PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error)
+	GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error)
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+	PutBucketLogging(ctx context.Context, params *s3.PutBucketLoggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketLoggingOutput, error)
+	ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error)
+	GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error)
+	SelectObjectContent(ctx context.Context, params *s3.SelectObjectContentInput, optFns ...func(*s3.Options)) (*s3.SelectObjectContentOutput, error)
+	ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error)
+}
+
+var _ S3API = (*s3.Client)(nil)
+
+// defaultRegion is the region of buckets created without a location constraint.
+const defaultRegion = "us-east-1"
+
 type Client struct {
-	client *s3.Client
-	region string
+	client S3API
+	// s3Client is the SDK client wrapped by client, nil if the client was created on top of another S3API.
+	s3Client *s3.Client
+	region   string
+	options  clientOptions
 }
 
 // NewClient creates a new client.
-func NewClient(ctx context.Context, region string) (*Client, error) {
+// The region selects the partition of the endpoints, e.g. us-gov-west-1 for GovCloud or cn-north-1 for China;
+// an empty region is taken from the shared config or the environment.
+func NewClient(ctx context.Context, region string, opts ...Option) (*Client, error) {
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.region != "" {
+		region = o.region
+	}
+
+	loadOptions := o.loadOptions()
+	if region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(region))
+	}
+
 	// Loading configuration from ~/.aws/* or ENV
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return nil, NewSDKError("unable to load SDK config", err)
 	}
 
+	return newClientFromConfig(cfg, o), nil
+}
+
+// NewClientFromConfig creates a new client from an already loaded SDK config, reusing its credentials,
+// retryer and HTTP client instead of loading the config again. A non-empty region overrides the config region.
+// WithProfile, WithCredentials, WithRetry, WithBaseRetryDelay, WithMaxBackoff, WithHTTPClient and WithInsecure
+// configure the loading of the config and are rejected; set them in the config instead.
+// WithRetryBudget limits the retryer of the config.
+func NewClientFromConfig(cfg aws.Config, region string, opts ...Option) (*Client, error) {
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The retry budget limits the retryer of the config instead of a retryer loaded with the config.
+	loadOpts := o
+	loadOpts.retryBudget = 0
+
+	if len(loadOpts.loadOptions()) > 0 {
+		return nil, NewValidationError("profile, credentials, retry, HTTP client and insecure options require NewClient")
+	}
+
+	if o.retryBudget > 0 {
+		newRetryer := cfg.Retryer
+		cfg.Retryer = func() aws.Retryer {
+			if newRetryer == nil {
+				return budgetRetryer{Retryer: retry.NewStandard()}
+			}
+
+			return budgetRetryer{Retryer: newRetryer()}
+		}
+	}
+
+	if o.region != "" {
+		region = o.region
+	}
+
+	if region != "" {
+		cfg.Region = region
+	}
+
+	return newClientFromConfig(cfg, o), nil
+}
+
+func newClientFromConfig(cfg aws.Config, o clientOptions) *Client {
+	if o.assumeRole != nil {
+		cfg.Credentials = newAssumeRoleProvider(sts.NewFromConfig(cfg), o.assumeRole)
+	}
+
 	// Creating the S3 client
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, o.s3Options()...)
+
+	// The resolved region also covers a region taken from the shared config or the environment.
+	return newClient(client, cfg.Region, newRegionAPIFunc(client), o)
+}
+
+// NewClientWithAPI creates a new client on top of the given S3 API implementation.
+// WithOperationRegion and WithBucketRegionDetection are supported only if api is an *s3.Client.
+// The options configuring the SDK client, i.e. the retry, retry budget, credentials, assume role, endpoint,
+// HTTP client, insecure and user agent options, are rejected; configure the API implementation instead.
+func NewClientWithAPI(api S3API, region string, opts ...Option) (*Client, error) {
+	o, err := newClientOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(o.loadOptions()) > 0 || len(o.s3Options()) > 0 || o.assumeRole != nil {
+		return nil, NewValidationError("retry, retry budget, credentials, assume role, endpoint, HTTP client, insecure and user agent options require NewClient")
+	}
+
+	if o.region != "" {
+		region = o.region
+	}
+
+	newRegionAPI := newRegionAPIFunc(api)
+	if newRegionAPI == nil && o.detectBucketRegion {
+		return nil, NewValidationError("bucket region detection requires an *s3.Client API")
+	}
+
+	return newClient(api, region, newRegionAPI, o), nil
+}
+
+func newClient(api S3API, region string, newRegionAPI func(region string) S3API, o clientOptions) *Client {
+	s3Client, _ := api.(*s3.Client)
 
 	return &Client{
-		client: client,
-		region: region,
-	}, nil
+		client: &instrumentedAPI{
+			api: &regionClients{
+				api:    api,
+				region: region,
+				newAPI: newRegionAPI,
+			},
+			options: o,
+		},
+		s3Client: s3Client,
+		region:   region,
+		options:  o,
+	}
+}
+
+// UnderlyingClient returns the SDK client used by the client, as an escape hatch for the operations
+// the package doesn't support. It returns nil if the client was created with NewClientWithAPI
+// on top of an S3API other than *s3.Client.
+// Calls made through it bypass the validation, error wrapping, timeouts, logging and observation of the package.
+func (s *Client) UnderlyingClient() *s3.Client {
+	return s.s3Client
+}
+
+// Region returns the region of the client, which is also the region of the buckets created by CreateBucket.
+// It is empty for a client created by NewClientWithAPI without a region.
+func (s *Client) Region() string {
+	return s.region
+}
+
+// Close closes the idle connections of the HTTP client, e.g. on graceful shutdown. Requests can still be made
+// afterwards and open new connections. It is a no-op if the HTTP client can't close its idle connections,
+// or if the client was created with NewClientWithAPI on top of an S3API other than *s3.Client.
+func (s *Client) Close() error {
+	if s.s3Client == nil {
+		return nil
+	}
+
+	switch httpClient := s.s3Client.Options().HTTPClient.(type) {
+	case interface{ CloseIdleConnections() }:
+		httpClient.CloseIdleConnections()
+	case interface{ GetTransport() *http.Transport }:
+		// The default client of the SDK exposes its transport instead.
+		httpClient.GetTransport().CloseIdleConnections()
+	}
+
+	return nil
 }
 
 // UploadFileBase uploads a file.
-func (s *Client) UploadFileBase(ctx context.Context, bucketName string, directory string, filePath string, externalFilename string) error {
-	if bucketName == "" {
-		return NewValidationError("bucket name is empty")
+func (s *Client) UploadFileBase(ctx context.Context, bucketName string, directory string, filePath string, externalFilename string, opts ...UploadOption) (UploadInfo, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return UploadInfo{}, err
 	}
 
 	if directory == "" {
-		return NewValidationError("directory is empty")
+		return UploadInfo{}, NewValidationError("directory is empty")
 	}
 
 	if filePath == "" {
-		return NewValidationError("file path is empty")
+		return UploadInfo{}, NewValidationError("file path is empty")
 	}
 
 	if externalFilename == "" {
-		return NewValidationError("external filename is empty")
+		return UploadInfo{}, NewValidationError("external filename is empty")
 	}
 
-	objectKey := generateObjectKeyBase(directory, externalFilename)
-
-	file, err := os.Open(filePath)
+	o, err := newUploadOptions(opts)
 	if err != nil {
-		return NewSDKError("unable to open file", err)
+		return UploadInfo{}, err
 	}
 
-	defer file.Close()
-
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return NewSDKError("unable to get file info", err)
+	if o.contentLength != nil {
+		return UploadInfo{}, NewValidationError("content length is supported by UploadReader only")
 	}
 
-	if fileInfo.Size() == 0 {
-		return NewValidationError("file is empty")
+	if err := o.validateFilenameUpload("UploadFileBase"); err != nil {
+		return UploadInfo{}, err
 	}
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-		Body:   file,
-	})
-	if err != nil {
-		return NewS3Error("unable to upload file", err)
-	}
-
-	return err
+	return s.putFile(ctx, bucketName, o.objectKey(directory, externalFilename), filePath, o)
 }
 
 // UploadFileWithDateDestination uploads a file to folder with a specific date prefix.
-func (s *Client) UploadFileWithDateDestination(ctx context.Context, bucketName string, directory string, filePath string, date time.Time) error {
-	if bucketName == "" {
-		return NewValidationError("bucket name is empty")
+// With WithPartitionMarker an empty marker object is created in the date partition after the upload.
+func (s *Client) UploadFileWithDateDestination(ctx context.Context, bucketName string, directory string, filePath string, date time.Time, opts ...UploadOption) (UploadInfo, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return UploadInfo{}, err
 	}
 
 	if directory == "" {
-		return NewValidationError("directory is empty")
+		return UploadInfo{}, NewValidationError("directory is empty")
 	}
 
 	if filePath == "" {
-		return NewValidationError("file path is empty")
+		return UploadInfo{}, NewValidationError("file path is empty")
 	}
 
 	if date.IsZero() {
-		return NewValidationError("date is empty")
+		return UploadInfo{}, NewValidationError("date is empty")
 	}
 
-	objectKey := generateObjectKeyByDate(directory, filePath, date)
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	if o.contentLength != nil {
+		return UploadInfo{}, NewValidationError("content length is supported by UploadReader only")
+	}
+
+	if o.preserveSlashes {
+		return UploadInfo{}, NewValidationError("preserving slashes is not supported by UploadFileWithDateDestination")
+	}
+
+	if o.relativePath {
+		if err := validateRelativePath(filePath); err != nil {
+			return UploadInfo{}, err
+		}
+	}
+
+	objectKey := generateObjectKeyByDate(directory, filePath, date, o.relativePath, s.options.keyLayout())
+	if o.gzip {
+		objectKey += gzipExtension
+	}
+
+	info, err := s.putFile(ctx, bucketName, objectKey, filePath, o)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	if o.partitionMarker == "" {
+		return info, nil
+	}
+
+	markerKey := generateFolderDestinationByDate(directory, date, s.options.keyLayout()) + "/" + o.partitionMarker
+
+	input := s.newPutObjectInput(bucketName, markerKey, o)
+	input.Body = strings.NewReader("")
+	// The conditions of the upload apply to the data object, while the marker is created for every upload.
+	input.IfMatch = nil
+	input.IfNoneMatch = nil
+
+	_, err = s.client.PutObject(ctx, input)
+	if err != nil {
+		return UploadInfo{}, newPutObjectError("unable to create partition marker", err)
+	}
+
+	return info, nil
+}
+
+// putFile uploads a local file to the object key.
+func (s *Client) putFile(ctx context.Context, bucketName string, objectKey string, filePath string, o uploadOptions) (UploadInfo, error) {
+	if err := validateObjectKey(objectKey); err != nil {
+		return UploadInfo{}, err
+	}
 
 	file, err := os.Open(filePath)
 	if err != nil {
-		return NewSDKError("unable to open file", err)
+		return UploadInfo{}, NewSDKError("unable to open file", err)
 	}
 
 	defer file.Close()
 
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return NewSDKError("unable to get file info", err)
+		return UploadInfo{}, NewSDKError("unable to get file info", err)
 	}
 
-	if fileInfo.Size() == 0 {
-		return NewValidationError("file is empty")
+	if fileInfo.Size() == 0 && !o.allowEmpty {
+		return UploadInfo{}, NewValidationError("file is empty")
 	}
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-		Body:   file,
-	})
+	if o.gzip {
+		return s.putStream(ctx, bucketName, objectKey, file, o)
+	}
+
+	err = s.backupObject(ctx, bucketName, objectKey, o)
 	if err != nil {
-		return NewS3Error("unable to upload file", err)
+		return UploadInfo{}, err
 	}
 
-	return err
-}
+	input := s.newPutObjectInput(bucketName, objectKey, o)
+	input.Body = file
 
-// DeleteFolderByDate deletes all objects in a folder with a specific date prefix.
-func (s *Client) DeleteFolderByDate(ctx context.Context, bucketName string, directory string, date time.Time) error {
-	if bucketName == "" {
-		return NewValidationError("bucket name is empty")
+	if o.atomicUpload {
+		input.Key = aws.String(tempObjectKey(objectKey))
 	}
 
-	if directory == "" {
-		return NewValidationError("directory is empty")
+	var sum []byte
+	if o.contentMD5 || o.verifyETag {
+		sum, err = fileMD5(file)
+		if err != nil {
+			return UploadInfo{}, err
+		}
 	}
 
-	if date.IsZero() {
-		return NewValidationError("date is empty")
+	if o.contentMD5 {
+		input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(sum))
 	}
 
-	objectKey := generateFolderDestinationByDate(directory, date)
+	start := time.Now()
 
-	listObjectsInput := &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucketName),
-		Prefix: aws.String(objectKey),
+	putResp, err := s.putObject(ctx, input)
+	if err != nil {
+		return UploadInfo{}, newUploadError("unable to upload file", err, o)
 	}
 
-	listResp, err := s.client.ListObjectsV2(ctx, listObjectsInput)
+	duration := time.Since(start)
+
+	putResp, err = s.completeUpload(ctx, bucketName, aws.ToString(input.Key), objectKey, fileInfo.Size(), putResp, sum, o)
 	if err != nil {
-		return NewS3Error("unable to list objects", err)
+		return UploadInfo{}, err
 	}
 
-	deleteObjects := make([]types.ObjectIdentifier, 0, len(listResp.Contents))
-	for _, object := range listResp.Contents {
-		deleteObjects = append(deleteObjects, types.ObjectIdentifier{
-			Key: aws.String(*object.Key),
-		})
-	}
+	return UploadInfo{
+		Key:               objectKey,
+		ETag:              aws.ToString(putResp.ETag),
+		ChecksumAlgorithm: o.checksumAlgorithm,
+		Checksum:          checksumValue(o.checksumAlgorithm, putResp.ChecksumCRC32, putResp.ChecksumCRC32C, putResp.ChecksumSHA1, putResp.ChecksumSHA256),
+		Size:              fileInfo.Size(),
+		Duration:          duration,
+	}, nil
+}
 
-	if len(deleteObjects) == 0 {
+// backupObject copies the object to the backup key if WithBackupOnOverwrite is set and the object exists.
+func (s *Client) backupObject(ctx context.Context, bucketName string, key string, o uploadOptions) error {
+	if o.backupSuffix == "" {
 		return nil
 	}
 
-	deleteInput := &s3.DeleteObjectsInput{
+	headResp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(bucketName),
-		Delete: &types.Delete{
-			Objects: deleteObjects,
-			Quiet:   aws.Bool(false),
-		},
-	}
-
-	_, err = s.client.DeleteObjects(ctx, deleteInput)
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return NewS3Error("unable to delete objects", err)
+		if isNotFound(err) {
+			return nil
+		}
+
+		return NewS3Error("unable to get object info", err)
 	}
 
-	return nil
+	_, err = s.copyObject(ctx, bucketName, key, bucketName, key+o.backupSuffix, aws.ToInt64(headResp.ContentLength), copyOptions{})
+
+	return err
 }
 
-// DeleteFolder deletes all objects in a folder.
-func (s *Client) DeleteFolder(ctx context.Context, bucketName string, directory string) error {
+// DeleteFolderByDate deletes all objects in a folder with a specific date prefix.
+func (s *Client) DeleteFolderByDate(ctx context.Context, bucketName string, directory string, date time.Time) error {
 	if bucketName == "" {
 		return NewValidationError("bucket name is empty")
 	}
@@ -195,45 +430,53 @@ func (s *Client) DeleteFolder(ctx context.Context, bucketName string, directory
 		return NewValidationError("directory is empty")
 	}
 
-	listObjectsInput := &s3.ListObjectsV2Input{
-		Bucket: aws.String(bucketName),
-		Prefix: aws.String(directory),
+	if date.IsZero() {
+		return NewValidationError("date is empty")
 	}
 
-	listResp, err := s.client.ListObjectsV2(ctx, listObjectsInput)
-	if err != nil {
-		return NewS3Error("unable to list objects", err)
-	}
+	objectKey := generateFolderDestinationByDate(directory, date, s.options.keyLayout())
 
-	deleteObjects := make([]types.ObjectIdentifier, 0, len(listResp.Contents))
-	for _, object := range listResp.Contents {
-		deleteObjects = append(deleteObjects, types.ObjectIdentifier{
-			Key: aws.String(*object.Key),
-		})
-	}
+	_, err := s.deletePrefix(ctx, bucketName, objectKey)
 
-	if len(deleteObjects) == 0 {
-		return nil
+	return err
+}
+
+// DeleteFolder deletes all objects in a folder and returns the deleted keys, e.g. for an audit trail.
+// If some objects could not be deleted, the keys deleted so far are returned along with the error.
+func (s *Client) DeleteFolder(ctx context.Context, bucketName string, directory string) ([]string, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
 	}
 
-	deleteInput := &s3.DeleteObjectsInput{
-		Bucket: aws.String(bucketName),
-		Delete: &types.Delete{
-			Objects: deleteObjects,
-			Quiet:   aws.Bool(false),
-		},
+	if directory == "" {
+		return nil, NewValidationError("directory is empty")
 	}
 
-	_, err = s.client.DeleteObjects(ctx, deleteInput)
+	return s.deletePrefix(ctx, bucketName, directory)
+}
+
+// deletePrefix deletes all objects under the prefix and returns the deleted keys.
+func (s *Client) deletePrefix(ctx context.Context, bucketName string, prefix string) ([]string, error) {
+	ctx = s.startRetryBudget(ctx)
+
+	var keys []string
+
+	err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		keys = append(keys, aws.ToString(object.Key))
+
+		return nil
+	})
 	if err != nil {
-		return NewS3Error("unable to delete objects", err)
+		return nil, err
 	}
 
-	return nil
+	// The listed keys are known, so the confirmations of the verbose response are not needed.
+	return s.deleteKeys(ctx, bucketName, keys, true)
 }
 
 // DeleteObject delete object by key.
-func (s *Client) DeleteObject(ctx context.Context, bucketName string, key string) error {
+// In a versioned bucket it only adds a delete marker, unless HardDelete is set.
+func (s *Client) DeleteObject(ctx context.Context, bucketName string, key string, opts ...DeleteOption) error {
 	if bucketName == "" {
 		return NewValidationError("bucket name is empty")
 	}
@@ -242,6 +485,11 @@ func (s *Client) DeleteObject(ctx context.Context, bucketName string, key string
 		return NewValidationError("key is empty")
 	}
 
+	o := newDeleteOptions(opts)
+	if o.hardDelete {
+		return s.hardDeleteObject(ctx, bucketName, key)
+	}
+
 	deleteObjectsInput := &s3.DeleteObjectInput{
 		Bucket: aws.String(bucketName),
 		Key:    &key,
@@ -255,7 +503,8 @@ func (s *Client) DeleteObject(ctx context.Context, bucketName string, key string
 	return nil
 }
 
-// IsObjectExists checks if object exists.
+// IsObjectExists reports whether an object with exactly the key exists.
+// Keys that are only a prefix of other objects, such as folders, are not objects; use IsPrefixEmpty for them.
 func (s *Client) IsObjectExists(ctx context.Context, bucketName string, key string) (bool, error) {
 	if bucketName == "" {
 		return false, NewValidationError("bucket name is empty")
@@ -267,96 +516,165 @@ func (s *Client) IsObjectExists(ctx context.Context, bucketName string, key stri
 
 	key = strings.Trim(key, "/")
 
-	listObjectsInput := &s3.ListObjectsV2Input{
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(bucketName),
-		Prefix: &key,
-	}
-
-	listObjectsResp, err := s.client.ListObjectsV2(ctx, listObjectsInput)
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		return false, NewS3Error("unable to list objects", err)
-	}
+		if isNotFound(err) {
+			return false, nil
+		}
 
-	if len(listObjectsResp.Contents) == 0 {
-		return false, nil
+		return false, NewS3Error("unable to get object info", err)
 	}
 
 	return true, nil
 }
 
 // GetObject downloads object.
-func (s *Client) GetObject(ctx context.Context, bucketName string, key string, localPath string) error {
+// A missing object results in an S3Error matching ErrObjectNotFound, and the local file is not created.
+func (s *Client) GetObject(ctx context.Context, bucketName string, key string, localPath string, opts ...DownloadOption) error {
+	_, err := s.GetObjectWithResult(ctx, bucketName, key, localPath, opts...)
+
+	return err
+}
+
+// GetObjectResult describes an object downloaded by GetObjectWithResult.
+type GetObjectResult struct {
+	// BytesWritten is the number of bytes written to the local file, after decompression with WithDecompress.
+	BytesWritten int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// GetObjectWithResult downloads the object like GetObject and returns the number of written bytes
+// and the object headers, saving a HeadObject request.
+func (s *Client) GetObjectWithResult(ctx context.Context, bucketName string, key string, localPath string, opts ...DownloadOption) (GetObjectResult, error) {
 	if bucketName == "" {
-		return NewValidationError("bucket name is empty")
+		return GetObjectResult{}, NewValidationError("bucket name is empty")
 	}
 
 	if key == "" {
-		return NewValidationError("key is empty")
+		return GetObjectResult{}, NewValidationError("key is empty")
 	}
 
 	if localPath == "" {
-		return NewValidationError("local path is empty")
+		return GetObjectResult{}, NewValidationError("local path is empty")
 	}
 
 	key = strings.Trim(key, "/")
 
-	getObjectInput := &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    &key,
-	}
+	o := newDownloadOptions(opts)
 
-	result, err := s.client.GetObject(ctx, getObjectInput)
+	output, err := s.client.GetObject(ctx, newGetObjectInput(bucketName, key, o))
 	if err != nil {
-		return NewS3Error("unable to get object", err)
+		return GetObjectResult{}, newGetObjectError("unable to get object", err)
 	}
 
-	defer result.Body.Close()
+	defer output.Body.Close()
 
-	file, err := os.Create(localPath)
-	if err != nil {
-		return NewSDKError("unable to create file", err)
+	result := GetObjectResult{
+		ContentType:  aws.ToString(output.ContentType),
+		ETag:         aws.ToString(output.ETag),
+		LastModified: aws.ToTime(output.LastModified),
 	}
 
-	defer file.Close()
+	verify := verifyDownloadETag(output, o)
 
-	body, err := io.ReadAll(result.Body)
-	if err != nil {
-		return NewSDKError("unable to read S3 response body", err)
-	}
+	err = writeFileAtomic(localPath, func(file *os.File) error {
+		result.BytesWritten, err = copyBody(ctx, file, output, o)
+		if err != nil {
+			return err
+		}
 
-	_, err = file.Write(body)
+		return verify()
+	})
 	if err != nil {
-		return NewSDKError("unable to write file", err)
+		return GetObjectResult{}, err
 	}
 
-	return nil
+	return result, nil
 }
 
 // CreateBucket creates bucket.
+// An existing bucket results in an error matching ErrBucketAlreadyOwned if the caller owns it
+// and ErrBucketAlreadyExists otherwise.
 func (s *Client) CreateBucket(ctx context.Context, bucketName string) error {
-	if bucketName == "" {
-		return NewValidationError("bucket name is empty")
+	if err := validateBucketName(bucketName); err != nil {
+		return err
 	}
 
-	_, err := s.client.CreateBucket(ctx, &s3.CreateBucketInput{
+	input := &s3.CreateBucketInput{
 		Bucket: aws.String(bucketName),
-		CreateBucketConfiguration: &types.CreateBucketConfiguration{
+	}
+
+	// us-east-1 is the default location, which S3 rejects as an explicit constraint.
+	if s.region != "" && s.region != defaultRegion {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
 			LocationConstraint: types.BucketLocationConstraint(s.region),
-		},
-	})
+		}
+	}
+
+	_, err := s.client.CreateBucket(ctx, input)
 	if err != nil {
-		return NewS3Error("unable to create bucket", err)
+		return newCreateBucketError("unable to create bucket", err)
 	}
 
 	return err
 }
 
-func generateObjectKeyByDate(directory string, filePath string, date time.Time) string {
-	directory = strings.Trim(directory, "/")
-	fileName := strings.Split(filePath, "/")[len(strings.Split(filePath, "/"))-1]
-	objectKey := fmt.Sprintf("%s/_year=%v/_month=%v/_day=%v/_date=%v/%s", directory, date.Year(), date.Format("01"), date.Format("02"), date.Format(time.DateOnly), fileName)
+// CreateBucketIfNotExists creates the bucket unless the caller already owns it.
+// A bucket name taken by another account still results in an error matching ErrBucketAlreadyExists.
+func (s *Client) CreateBucketIfNotExists(ctx context.Context, bucketName string) error {
+	err := s.CreateBucket(ctx, bucketName)
+	if errors.Is(err, ErrBucketAlreadyOwned) {
+		return nil
+	}
 
-	return objectKey
+	return err
+}
+
+// BuildDateKey returns the key of the object uploaded by UploadFileWithDateDestination without options
+// in the key layout of the client, e.g. to record it before the upload. WithRelativePath and WithGzip change the key.
+func (s *Client) BuildDateKey(directory string, filePath string, date time.Time) string {
+	return generateObjectKeyByDate(directory, filePath, date, false, s.options.keyLayout())
+}
+
+// BuildKey returns the key of the object uploaded by UploadFileBase or UploadReader without options.
+// PreserveSlashes and WithGzip change the key.
+func BuildKey(directory string, filename string) string {
+	return generateObjectKeyBase(directory, filename)
+}
+
+// generateObjectKeyByDate places the file name of the file path in the date partition of the directory.
+// With keepPath the whole relative file path is placed there instead, keeping its subfolders.
+// Backslashes are treated as path separators, so Windows paths yield the same key on every OS.
+func generateObjectKeyByDate(directory string, filePath string, date time.Time, keepPath bool, layout KeyLayout) string {
+	fileName := path.Clean(strings.ReplaceAll(filepath.ToSlash(filePath), `\`, "/"))
+
+	if !keepPath {
+		fileName = path.Base(fileName)
+	}
+
+	return generateFolderDestinationByDate(directory, date, layout) + "/" + fileName
+}
+
+// validateRelativePath checks that the file path is a relative path inside the working directory,
+// so that it can be appended to an object key as is.
+func validateRelativePath(filePath string) error {
+	slashed := strings.ReplaceAll(filepath.ToSlash(filePath), `\`, "/")
+
+	if path.IsAbs(slashed) || filepath.IsAbs(filePath) || (len(slashed) > 1 && slashed[1] == ':') {
+		return NewValidationError("file path must be relative: " + filePath)
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return NewValidationError("file path must not leave the working directory: " + filePath)
+	}
+
+	return nil
 }
 
 func generateObjectKeyBase(directory string, filename string) string {
@@ -366,9 +684,17 @@ func generateObjectKeyBase(directory string, filename string) string {
 	return objectKey
 }
 
-func generateFolderDestinationByDate(directory string, date time.Time) string {
+// generateObjectKeyPreserved joins the directory and the filename without trimming slashes from the directory.
+func generateObjectKeyPreserved(directory string, filename string) string {
+	if strings.HasSuffix(directory, "/") {
+		return directory + filename
+	}
+
+	return directory + "/" + filename
+}
+
+func generateFolderDestinationByDate(directory string, date time.Time, layout KeyLayout) string {
 	directory = strings.Trim(directory, "/")
-	objectKey := fmt.Sprintf("%s/_year=%v/_month=%v/_day=%v/_date=%v", directory, date.Year(), date.Format("01"), date.Format("02"), date.Format(time.DateOnly))
 
-	return objectKey
+	return directory + "/" + layout.partition(date)
 }
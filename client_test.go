@@ -1,8 +1,23 @@
 package s3utils
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyendpoints "github.com/aws/smithy-go/endpoints"
 )
 
 func Test_generateObjectKeyByDate(t *testing.T) {
@@ -10,6 +25,7 @@ func Test_generateObjectKeyByDate(t *testing.T) {
 		destination string
 		fileName    string
 		date        time.Time
+		keepPath    bool
 	}
 
 	tests := []struct {
@@ -62,11 +78,69 @@ func Test_generateObjectKeyByDate(t *testing.T) {
 			},
 			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/test.json",
 		},
+		{
+			name: "windows_path",
+			args: args{
+				destination: "directory/raw",
+				fileName:    `local_dir\test.json`,
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/test.json",
+		},
+		{
+			name: "windows_absolute_path",
+			args: args{
+				destination: "directory/raw",
+				fileName:    `C:\Users\etl\export\test.json`,
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/test.json",
+		},
+		{
+			name: "keep_path",
+			args: args{
+				destination: "directory/raw",
+				fileName:    "region/us/data.csv",
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+				keepPath:    true,
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/region/us/data.csv",
+		},
+		{
+			name: "keep_path_dot_prefix",
+			args: args{
+				destination: "directory/raw",
+				fileName:    "./region//us/data.csv",
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+				keepPath:    true,
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/region/us/data.csv",
+		},
+		{
+			name: "keep_path_windows",
+			args: args{
+				destination: "directory/raw",
+				fileName:    `region\us\data.csv`,
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+				keepPath:    true,
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/region/us/data.csv",
+		},
+		{
+			name: "keep_path_file_name",
+			args: args{
+				destination: "directory/raw",
+				fileName:    "data.csv",
+				date:        time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+				keepPath:    true,
+			},
+			want: "directory/raw/_year=2024/_month=09/_day=30/_date=2024-09-30/data.csv",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := generateObjectKeyByDate(tt.args.destination, tt.args.fileName, tt.args.date); got != tt.want {
+			if got := generateObjectKeyByDate(tt.args.destination, tt.args.fileName, tt.args.date, tt.args.keepPath, DefaultKeyLayout()); got != tt.want {
 				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
 			}
 		})
@@ -128,7 +202,7 @@ func Test_generateFolderDestinationByDate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := generateFolderDestinationByDate(tt.args.destination, tt.args.date); got != tt.want {
+			if got := generateFolderDestinationByDate(tt.args.destination, tt.args.date, DefaultKeyLayout()); got != tt.want {
 				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
 			}
 		})
@@ -168,3 +242,569 @@ func Test_generateObjectKeyBase(t *testing.T) {
 		})
 	}
 }
+
+func Test_generateObjectKeyPreserved(t *testing.T) {
+	type args struct {
+		directory string
+		filename  string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{{
+		name: "base",
+		args: args{
+			directory: "raw/test",
+			filename:  "test.json",
+		},
+		want: "raw/test/test.json",
+	}, {
+		name: "leading_slash",
+		args: args{
+			directory: "/raw/test",
+			filename:  "test.json",
+		},
+		want: "/raw/test/test.json",
+	}, {
+		name: "leading_and_trailing_slash",
+		args: args{
+			directory: "/raw/test/",
+			filename:  "test.json",
+		},
+		want: "/raw/test/test.json",
+	}, {
+		name: "double_trailing_slash",
+		args: args{
+			directory: "/raw/test//",
+			filename:  "test.json",
+		},
+		want: "/raw/test//test.json",
+	},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generateObjectKeyPreserved(tt.args.directory, tt.args.filename); got != tt.want {
+				t.Errorf("generateObjectKeyPreserved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient_profile(t *testing.T) {
+	_, err := NewClient(context.Background(), "us-east-1",
+		WithProfile("analytics"),
+		WithCredentials(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+	)
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	o, err := newClientOptions([]Option{WithProfile("analytics")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var loadOptions config.LoadOptions
+	for _, fn := range o.loadOptions() {
+		if err := fn(&loadOptions); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if loadOptions.SharedConfigProfile != "analytics" {
+		t.Errorf("actual `%v` \n expected `%v`", loadOptions.SharedConfigProfile, "analytics")
+	}
+}
+
+type staticResolver struct {
+	host string
+}
+
+func (r staticResolver) ResolveEndpoint(_ context.Context, params s3.EndpointParameters) (smithyendpoints.Endpoint, error) {
+	return smithyendpoints.Endpoint{
+		URI: url.URL{Scheme: "https", Host: r.host, Path: "/" + aws.ToString(params.Bucket)},
+	}, nil
+}
+
+func TestWithEndpointResolver(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   string
+		opts     []Option
+		wantHost string
+	}{
+		{
+			name:     "china_partition",
+			region:   "cn-north-1",
+			wantHost: "bucket.s3.cn-north-1.amazonaws.com.cn",
+		},
+		{
+			name:     "govcloud_partition",
+			region:   "us-gov-west-1",
+			wantHost: "bucket.s3.us-gov-west-1.amazonaws.com",
+		},
+		{
+			name:     "custom_resolver",
+			region:   "cn-north-1",
+			opts:     []Option{WithEndpointResolver(staticResolver{host: "s3.internal.example"})},
+			wantHost: "s3.internal.example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := newClientOptions(tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusNoContent}}}
+
+			api := s3.New(s3.Options{
+				Region:      tt.region,
+				Credentials: aws.AnonymousCredentials{},
+				HTTPClient:  httpClient,
+			}, o.s3Options()...)
+
+			client := newTestClient(t, api)
+
+			if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if host := httpClient.requests[0].URL.Host; host != tt.wantHost {
+				t.Errorf("actual `%v` \n expected `%v`", host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestClient_CreateBucket_locationConstraint(t *testing.T) {
+	tests := []struct {
+		region string
+		want   types.BucketLocationConstraint
+	}{
+		{
+			region: "us-east-1",
+			want:   "",
+		},
+		{
+			region: "cn-north-1",
+			want:   types.BucketLocationConstraintCnNorth1,
+		},
+		{
+			region: "us-gov-west-1",
+			want:   types.BucketLocationConstraintUsGovWest1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			fake := newFakeS3()
+
+			client, err := NewClientWithAPI(fake, tt.region)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := client.CreateBucket(context.Background(), "bucket"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got types.BucketLocationConstraint
+			if configuration := fake.createBucketInputs[0].CreateBucketConfiguration; configuration != nil {
+				got = configuration.LocationConstraint
+			}
+
+			if got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_CreateBucket_alreadyExists(t *testing.T) {
+	fake := newFakeS3()
+	fake.foreignBuckets = map[string]bool{"taken": true}
+	client := newTestClient(t, fake)
+
+	if err := client.CreateBucket(context.Background(), "bucket"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := client.CreateBucket(context.Background(), "bucket")
+	if !errors.Is(err, ErrBucketAlreadyOwned) {
+		t.Errorf("actual error `%v` \n expected `%v`", err, ErrBucketAlreadyOwned)
+	}
+
+	err = client.CreateBucket(context.Background(), "taken")
+	if !errors.Is(err, ErrBucketAlreadyExists) {
+		t.Errorf("actual error `%v` \n expected `%v`", err, ErrBucketAlreadyExists)
+	}
+
+	if err := client.CreateBucketIfNotExists(context.Background(), "bucket"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := client.CreateBucketIfNotExists(context.Background(), "new"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err = client.CreateBucketIfNotExists(context.Background(), "taken")
+	if !errors.Is(err, ErrBucketAlreadyExists) {
+		t.Errorf("actual error `%v` \n expected `%v`", err, ErrBucketAlreadyExists)
+	}
+}
+
+func TestClient_UnderlyingClient(t *testing.T) {
+	api := s3.New(s3.Options{Region: "us-east-1"})
+
+	client, err := NewClientWithAPI(api, "us-east-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client.UnderlyingClient() != api {
+		t.Errorf("actual `%p` \n expected `%p`", client.UnderlyingClient(), api)
+	}
+
+	if got := newTestClient(t, newFakeS3()).UnderlyingClient(); got != nil {
+		t.Errorf("actual `%p` \n expected nil", got)
+	}
+}
+
+// recordingTransport records the requests and answers them with 204 No Content.
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+
+	return &http.Response{
+		StatusCode: http.StatusNoContent,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	transport := &recordingTransport{}
+
+	client, err := NewClient(context.Background(), "eu-west-1",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithCredentials(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(transport.requests) != 1 {
+		t.Fatalf("actual %d requests \n expected 1", len(transport.requests))
+	}
+
+	if host := transport.requests[0].URL.Host; host != "bucket.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("actual `%v` \n expected `bucket.s3.eu-west-1.amazonaws.com`", host)
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	transport := &recordingTransport{}
+
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		HTTPClient:  &http.Client{Transport: transport},
+	}
+
+	client, err := NewClientFromConfig(cfg, "eu-west-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client.Region() != "eu-west-1" {
+		t.Errorf("actual `%v` \n expected `eu-west-1`", client.Region())
+	}
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(transport.requests) != 1 {
+		t.Fatalf("actual %d requests \n expected 1", len(transport.requests))
+	}
+
+	if host := transport.requests[0].URL.Host; host != "bucket.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("actual `%v` \n expected `bucket.s3.eu-west-1.amazonaws.com`", host)
+	}
+
+	client, err = NewClientFromConfig(cfg, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client.Region() != "us-east-1" {
+		t.Errorf("actual `%v` \n expected `us-east-1`", client.Region())
+	}
+
+	var validationErr ValidationError
+	if _, err := NewClientFromConfig(cfg, "", WithProfile("etl")); !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestNewClientWithAPI_sdkOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{
+			name: "retry",
+			opt:  WithRetry(3, time.Second),
+		},
+		{
+			name: "base_retry_delay",
+			opt:  WithBaseRetryDelay(time.Second),
+		},
+		{
+			name: "max_backoff",
+			opt:  WithMaxBackoff(time.Minute),
+		},
+		{
+			name: "profile",
+			opt:  WithProfile("etl"),
+		},
+		{
+			name: "credentials",
+			opt:  WithCredentials(aws.AnonymousCredentials{}),
+		},
+		{
+			name: "assume_role",
+			opt:  WithAssumeRole("arn:aws:iam::123456789012:role/etl", "etl"),
+		},
+		{
+			name: "endpoint",
+			opt:  WithEndpoint("https://minio.internal:9000"),
+		},
+		{
+			name: "endpoint_resolver",
+			opt:  WithEndpointResolver(s3.NewDefaultEndpointResolverV2()),
+		},
+		{
+			name: "path_style",
+			opt:  WithPathStyle(),
+		},
+		{
+			name: "insecure",
+			opt:  WithInsecure(),
+		},
+		{
+			name: "http_client",
+			opt:  WithHTTPClient(&http.Client{}),
+		},
+		{
+			name: "user_agent",
+			opt:  WithUserAgent("etl/1.0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClientWithAPI(newFakeS3(), "us-east-1", tt.opt)
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+// closingHTTPClient records the calls of CloseIdleConnections.
+type closingHTTPClient struct {
+	fakeHTTPClient
+
+	closed int
+}
+
+func (c *closingHTTPClient) CloseIdleConnections() {
+	c.closed++
+}
+
+func TestClient_Close(t *testing.T) {
+	httpClient := &closingHTTPClient{}
+
+	client := newHTTPTestClient(t, httpClient, nil)
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if httpClient.closed != 1 {
+		t.Errorf("actual %d calls \n expected 1", httpClient.closed)
+	}
+
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	defaultClient, err := NewClient(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := defaultClient.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := newTestClient(t, newFakeS3()).Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewClient_region(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+	t.Setenv("AWS_REGION", "ap-south-1")
+
+	tests := []struct {
+		name   string
+		region string
+		opts   []Option
+		want   string
+	}{
+		{
+			name:   "argument",
+			region: "eu-west-2",
+			want:   "eu-west-2",
+		},
+		{
+			name:   "environment",
+			region: "",
+			want:   "ap-south-1",
+		},
+		{
+			name:   "option",
+			region: "eu-west-2",
+			opts:   []Option{WithRegion("us-west-2")},
+			want:   "us-west-2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(context.Background(), tt.region, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if client.Region() != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", client.Region(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWithInsecure(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	var paths []string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	tlsServer := httptest.NewTLSServer(handler)
+	defer tlsServer.Close()
+
+	tests := []struct {
+		name     string
+		endpoint string
+	}{
+		{
+			name:     "http",
+			endpoint: strings.TrimPrefix(httpServer.URL, "http://"),
+		},
+		{
+			name:     "self_signed_tls",
+			endpoint: tlsServer.URL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = nil
+
+			client, err := NewClient(context.Background(), "us-east-1",
+				WithEndpoint(tt.endpoint),
+				WithPathStyle(),
+				WithInsecure(),
+				WithCredentials(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(paths, []string{"/bucket/raw/test.json"}) {
+				t.Errorf("actual `%v` \n expected `%v`", paths, []string{"/bucket/raw/test.json"})
+			}
+		})
+	}
+
+	invalid := [][]Option{
+		{WithInsecure()},
+		{WithInsecure(), WithEndpoint("localhost:9000"), WithHTTPClient(&http.Client{})},
+	}
+
+	for _, opts := range invalid {
+		var validationErr ValidationError
+		if _, err := newClientOptions(opts); !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	var userAgent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), "us-east-1",
+		WithEndpoint(server.URL),
+		WithPathStyle(),
+		WithUserAgent("etl-loader"),
+		WithCredentials(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(userAgent, "aws-sdk-go-v2/") || !slices.Contains(strings.Fields(userAgent), "etl-loader") {
+		t.Errorf("actual `%v` \n expected the SDK user agent with the suffix `etl-loader`", userAgent)
+	}
+}
@@ -0,0 +1,378 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// maxCopyObjectSize is the largest source object that a single CopyObject request can copy.
+	maxCopyObjectSize = 5 * 1024 * 1024 * 1024
+	// copyPartSize is the size of each part in a multipart copy.
+	copyPartSize = 512 * 1024 * 1024
+)
+
+// CopySpec describes a single copy performed by CopyObjects.
+type CopySpec struct {
+	SrcBucket string
+	SrcKey    string
+	DstBucket string
+	DstKey    string
+}
+
+// CopyResult holds the outcome of a single CopySpec.
+type CopyResult struct {
+	Spec CopySpec
+	Err  error
+}
+
+// CopyReport summarizes the outcomes of CopyObjects.
+type CopyReport struct {
+	Results []CopyResult
+	Copied  int
+	Failed  int
+}
+
+// CopyObject copies an object. Sources larger than 5 GiB are copied with a multipart copy.
+// The metadata of the source is copied as is, unless replaced with WithReplaceMetadata or WithReplaceContentType.
+func (s *Client) CopyObject(ctx context.Context, srcBucket string, srcKey string, dstBucket string, dstKey string, opts ...CopyOption) error {
+	if srcBucket == "" {
+		return NewValidationError("source bucket name is empty")
+	}
+
+	if srcKey == "" {
+		return NewValidationError("source key is empty")
+	}
+
+	if err := validateBucketName(dstBucket); err != nil {
+		return err
+	}
+
+	if dstKey == "" {
+		return NewValidationError("destination key is empty")
+	}
+
+	o := newCopyOptions(opts)
+
+	headResp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return newGetObjectError("unable to get source object info", err)
+	}
+
+	_, err = s.copyObject(ctx, srcBucket, srcKey, dstBucket, dstKey, aws.ToInt64(headResp.ContentLength), o)
+
+	return err
+}
+
+// copyObject copies an object of a known size, using a multipart copy for sources larger than 5 GiB.
+// It returns the ETag and the checksums of the copy.
+func (s *Client) copyObject(ctx context.Context, srcBucket string, srcKey string, dstBucket string, dstKey string, size int64, o copyOptions) (*types.CopyObjectResult, error) {
+	if size > maxCopyObjectSize {
+		return s.multipartCopy(ctx, srcBucket, srcKey, dstBucket, dstKey, size, o)
+	}
+
+	input := &s3.CopyObjectInput{
+		Bucket:                  aws.String(dstBucket),
+		Key:                     aws.String(dstKey),
+		CopySource:              aws.String(copySource(srcBucket, srcKey)),
+		MetadataDirective:       types.MetadataDirectiveCopy,
+		StorageClass:            o.storageClass,
+		ACL:                     o.acl,
+		ServerSideEncryption:    o.serverSideEncryption,
+		SSEKMSKeyId:             o.kmsKeyID,
+		SSEKMSEncryptionContext: o.encryptionContext,
+		ChecksumAlgorithm:       o.checksumAlgorithm,
+	}
+
+	if o.replaceMetadata {
+		input.MetadataDirective = types.MetadataDirectiveReplace
+		input.ContentType = o.contentType
+		input.Metadata = o.metadata
+	}
+
+	copyResp, err := s.client.CopyObject(ctx, input)
+	if err != nil {
+		return nil, NewS3Error("unable to copy object", err)
+	}
+
+	if copyResp.CopyObjectResult == nil {
+		return &types.CopyObjectResult{}, nil
+	}
+
+	return copyResp.CopyObjectResult, nil
+}
+
+// CopyObjects copies objects concurrently using a pool of concurrency workers.
+// Per-item errors are stored in the report, whose results are in the same order as items.
+// The returned error is non-nil only if the context was cancelled.
+func (s *Client) CopyObjects(ctx context.Context, items []CopySpec, concurrency int) (CopyReport, error) {
+	if concurrency < 1 {
+		return CopyReport{}, NewValidationError("concurrency must be positive")
+	}
+
+	ctx = s.startRetryBudget(ctx)
+
+	results := make([]CopyResult, len(items))
+	for i, item := range items {
+		results[i].Spec = item
+	}
+
+	dispatched := runPool(ctx, len(items), concurrency, func(i int) {
+		item := items[i]
+		results[i].Err = s.CopyObject(ctx, item.SrcBucket, item.SrcKey, item.DstBucket, item.DstKey)
+	})
+
+	ctxErr := ctx.Err()
+	if ctxErr != nil {
+		for i := dispatched; i < len(items); i++ {
+			results[i].Err = ctxErr
+		}
+	}
+
+	report := CopyReport{
+		Results: results,
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			report.Failed++
+		} else {
+			report.Copied++
+		}
+	}
+
+	return report, ctxErr
+}
+
+// TransformObject streams the source object through the transform function into the destination object
+// without temporary files. The transform reads the source content from r and writes the result to w.
+// The upload options apply to the destination object, except WithContentLength, PreserveSlashes and the options
+// of UploadFileWithDateDestination, which are rejected with a ValidationError.
+func (s *Client) TransformObject(ctx context.Context, srcBucket string, srcKey string, dstBucket string, dstKey string, transform func(r io.Reader, w io.Writer) error, opts ...UploadOption) error {
+	if srcBucket == "" {
+		return NewValidationError("source bucket name is empty")
+	}
+
+	if srcKey == "" {
+		return NewValidationError("source key is empty")
+	}
+
+	if err := validateBucketName(dstBucket); err != nil {
+		return err
+	}
+
+	if dstKey == "" {
+		return NewValidationError("destination key is empty")
+	}
+
+	if transform == nil {
+		return NewValidationError("transform is nil")
+	}
+
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := o.validateKeyUpload("TransformObject"); err != nil {
+		return err
+	}
+
+	getResp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return newGetObjectError("unable to get object", err)
+	}
+
+	defer getResp.Body.Close()
+
+	pipeReader, pipeWriter := io.Pipe()
+	transformErrCh := make(chan error, 1)
+
+	go func() {
+		err := transform(getResp.Body, pipeWriter)
+		pipeWriter.CloseWithError(err)
+		transformErrCh <- err
+	}()
+
+	_, uploadErr := s.putStream(ctx, dstBucket, dstKey, pipeReader, o)
+
+	// Unblocks the transform if the upload stopped reading early.
+	pipeReader.Close()
+
+	transformErr := <-transformErrCh
+	if transformErr != nil && !errors.Is(transformErr, io.ErrClosedPipe) {
+		return NewSDKError("unable to transform object", transformErr)
+	}
+
+	return uploadErr
+}
+
+// multipartCopy copies the object in parts and returns the ETag and the checksums of the copy. As the parts carry
+// no object settings, the content headers, user metadata, tags and checksum algorithm of the source are read
+// and set on the copy, unless they are replaced by the copy options.
+func (s *Client) multipartCopy(ctx context.Context, srcBucket string, srcKey string, dstBucket string, dstKey string, size int64, o copyOptions) (*types.CopyObjectResult, error) {
+	headResp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(srcBucket),
+		Key:          aws.String(srcKey),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return nil, newGetObjectError("unable to get source object info", err)
+	}
+
+	createInput := &s3.CreateMultipartUploadInput{
+		Bucket:                  aws.String(dstBucket),
+		Key:                     aws.String(dstKey),
+		StorageClass:            o.storageClass,
+		ACL:                     o.acl,
+		ServerSideEncryption:    o.serverSideEncryption,
+		SSEKMSKeyId:             o.kmsKeyID,
+		SSEKMSEncryptionContext: o.encryptionContext,
+		ContentType:             headResp.ContentType,
+		ContentEncoding:         headResp.ContentEncoding,
+		ContentDisposition:      headResp.ContentDisposition,
+		ContentLanguage:         headResp.ContentLanguage,
+		CacheControl:            headResp.CacheControl,
+		Metadata:                headResp.Metadata,
+		ChecksumAlgorithm:       checksumAlgorithmOf(headResp.ChecksumCRC32, headResp.ChecksumCRC32C, headResp.ChecksumSHA1, headResp.ChecksumSHA256),
+	}
+
+	if o.replaceMetadata {
+		createInput.ContentType = o.contentType
+		createInput.Metadata = o.metadata
+	}
+
+	if o.checksumAlgorithm != "" {
+		createInput.ChecksumAlgorithm = o.checksumAlgorithm
+	}
+
+	tagResp, err := s.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(srcBucket),
+		Key:    aws.String(srcKey),
+	})
+	if err != nil {
+		return nil, newGetObjectError("unable to get source object tags", err)
+	}
+
+	if len(tagResp.TagSet) > 0 {
+		tags := make(map[string]string, len(tagResp.TagSet))
+		for _, tag := range tagResp.TagSet {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+
+		createInput.Tagging = aws.String(encodeTags(tags))
+	}
+
+	createResp, err := s.client.CreateMultipartUpload(ctx, createInput)
+	if err != nil {
+		return nil, NewS3Error("unable to create multipart upload", err)
+	}
+
+	parts := make([]types.CompletedPart, 0, (size+copyPartSize-1)/copyPartSize)
+
+	for start := int64(0); start < size; start += copyPartSize {
+		end := min(start+copyPartSize, size) - 1
+		partNumber := aws.Int32(int32(len(parts) + 1))
+
+		partResp, err := s.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(dstBucket),
+			Key:             aws.String(dstKey),
+			UploadId:        createResp.UploadId,
+			PartNumber:      partNumber,
+			CopySource:      aws.String(copySource(srcBucket, srcKey)),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		})
+		if err != nil {
+			s.abortMultipartUpload(ctx, dstBucket, dstKey, createResp.UploadId)
+
+			return nil, NewS3Error("unable to copy part", err)
+		}
+
+		if partResp.CopyPartResult == nil {
+			s.abortMultipartUpload(ctx, dstBucket, dstKey, createResp.UploadId)
+
+			return nil, NewSDKError("unable to copy part", fmt.Errorf("part %d: response has no copy part result", aws.ToInt32(partNumber)))
+		}
+
+		parts = append(parts, types.CompletedPart{
+			ETag:           partResp.CopyPartResult.ETag,
+			PartNumber:     partNumber,
+			ChecksumCRC32:  partResp.CopyPartResult.ChecksumCRC32,
+			ChecksumCRC32C: partResp.CopyPartResult.ChecksumCRC32C,
+			ChecksumSHA1:   partResp.CopyPartResult.ChecksumSHA1,
+			ChecksumSHA256: partResp.CopyPartResult.ChecksumSHA256,
+		})
+	}
+
+	completeResp, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(dstBucket),
+		Key:      aws.String(dstKey),
+		UploadId: createResp.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: parts,
+		},
+	})
+	if err != nil {
+		s.abortMultipartUpload(ctx, dstBucket, dstKey, createResp.UploadId)
+
+		return nil, NewS3Error("unable to complete multipart upload", err)
+	}
+
+	return &types.CopyObjectResult{
+		ETag:           completeResp.ETag,
+		ChecksumCRC32:  completeResp.ChecksumCRC32,
+		ChecksumCRC32C: completeResp.ChecksumCRC32C,
+		ChecksumSHA1:   completeResp.ChecksumSHA1,
+		ChecksumSHA256: completeResp.ChecksumSHA256,
+	}, nil
+}
+
+// abortMultipartUpload aborts a multipart upload so that its parts don't linger.
+// It ignores cancellation of ctx, since it is usually called on the failure path.
+func (s *Client) abortMultipartUpload(ctx context.Context, bucketName string, key string, uploadID *string) {
+	_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucketName),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
+
+// checksumAlgorithmOf returns the algorithm of the checksum present among the checksums of a response,
+// empty if there is none.
+func checksumAlgorithmOf(crc32 *string, crc32c *string, sha1 *string, sha256 *string) types.ChecksumAlgorithm {
+	switch {
+	case crc32 != nil:
+		return types.ChecksumAlgorithmCrc32
+	case crc32c != nil:
+		return types.ChecksumAlgorithmCrc32c
+	case sha1 != nil:
+		return types.ChecksumAlgorithmSha1
+	case sha256 != nil:
+		return types.ChecksumAlgorithmSha256
+	}
+
+	return ""
+}
+
+func copySource(bucketName string, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return bucketName + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,338 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestClient_CopyObjects(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/a.json", []byte(`{"a":1}`))
+	fake.put("src", "raw/b c.json", []byte(`{"b":2}`))
+
+	client := newTestClient(t, fake)
+
+	items := []CopySpec{
+		{SrcBucket: "src", SrcKey: "raw/a.json", DstBucket: "dst", DstKey: "archive/a.json"},
+		{SrcBucket: "src", SrcKey: "raw/b c.json", DstBucket: "dst", DstKey: "archive/b c.json"},
+		{SrcBucket: "src", SrcKey: "raw/missing.json", DstBucket: "dst", DstKey: "archive/missing.json"},
+	}
+
+	report, err := client.CopyObjects(context.Background(), items, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Copied != 2 || report.Failed != 1 {
+		t.Errorf("actual copied `%v` failed `%v` \n expected copied `2` failed `1`", report.Copied, report.Failed)
+	}
+
+	for i, result := range report.Results {
+		if result.Spec != items[i] {
+			t.Errorf("result %d: actual spec `%v` \n expected `%v`", i, result.Spec, items[i])
+		}
+	}
+
+	var s3Err S3Error
+	if !errors.As(report.Results[2].Err, &s3Err) {
+		t.Errorf("actual error `%v` \n expected S3Error", report.Results[2].Err)
+	}
+
+	for _, key := range []string{"archive/a.json", "archive/b c.json"} {
+		if _, ok := fake.get("dst", key); !ok {
+			t.Errorf("object `%v` was not copied", key)
+		}
+	}
+
+	if _, ok := fake.get("dst", "archive/missing.json"); ok {
+		t.Errorf("object `archive/missing.json` must not exist")
+	}
+}
+
+func Test_copySource(t *testing.T) {
+	tests := []struct {
+		name   string
+		bucket string
+		key    string
+		want   string
+	}{
+		{
+			name:   "base",
+			bucket: "bucket",
+			key:    "raw/test.json",
+			want:   "bucket/raw/test.json",
+		},
+		{
+			name:   "escaped",
+			bucket: "bucket",
+			key:    "raw/with space/test+1.json",
+			want:   "bucket/raw/with%20space/test+1.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := copySource(tt.bucket, tt.key); got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_TransformObject(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/data.txt", []byte("hello, world"))
+
+	client := newTestClient(t, fake)
+
+	upper := func(r io.Reader, w io.Writer) error {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(bytes.ToUpper(content))
+
+		return err
+	}
+
+	err := client.TransformObject(context.Background(), "src", "raw/data.txt", "dst", "processed/data.txt", upper)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, ok := fake.get("dst", "processed/data.txt")
+	if !ok {
+		t.Fatal("object `processed/data.txt` was not uploaded")
+	}
+
+	if string(object.body) != "HELLO, WORLD" {
+		t.Errorf("actual `%s` \n expected `HELLO, WORLD`", object.body)
+	}
+}
+
+func TestClient_TransformObject_uploadOptions(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/data.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake)
+
+	copyContent := func(r io.Reader, w io.Writer) error {
+		_, err := io.Copy(w, r)
+
+		return err
+	}
+
+	err := client.TransformObject(context.Background(), "src", "raw/data.json", "dst", "processed/data.json", copyContent,
+		WithStorageClass(types.StorageClassStandardIa), WithTags(map[string]string{"stage": "processed"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input := fake.putInputs[0]
+	if input.StorageClass != types.StorageClassStandardIa {
+		t.Errorf("actual storage class `%v` \n expected `%v`", input.StorageClass, types.StorageClassStandardIa)
+	}
+
+	if got := aws.ToString(input.ContentType); got != "application/json" {
+		t.Errorf("actual content type `%v` \n expected `application/json`", got)
+	}
+
+	if got := aws.ToString(input.Tagging); got != "stage=processed" {
+		t.Errorf("actual tagging `%v` \n expected `stage=processed`", got)
+	}
+
+	err = client.TransformObject(context.Background(), "src", "raw/missing.json", "dst", "processed/missing.json", copyContent)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+
+	var validationErr ValidationError
+
+	err = client.TransformObject(context.Background(), "src", "raw/data.json", "Invalid_Bucket", "processed/data.json", copyContent)
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	err = client.TransformObject(context.Background(), "src", "raw/data.json", "dst", "processed/data.json", copyContent, WithContentLength(7))
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_TransformObject_transformError(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/data.txt", []byte("hello, world"))
+
+	client := newTestClient(t, fake)
+
+	errTransform := errors.New("bad input")
+
+	err := client.TransformObject(context.Background(), "src", "raw/data.txt", "dst", "processed/data.txt", func(io.Reader, io.Writer) error {
+		return errTransform
+	})
+
+	var sdkErr SDKError
+	if !errors.As(err, &sdkErr) || !errors.Is(err, errTransform) {
+		t.Errorf("actual error `%v` \n expected SDKError wrapping `%v`", err, errTransform)
+	}
+
+	if _, ok := fake.get("dst", "processed/data.txt"); ok {
+		t.Errorf("object `processed/data.txt` must not be uploaded")
+	}
+}
+
+func TestClient_CopyObject_metadataDirective(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []CopyOption
+		wantDirective   types.MetadataDirective
+		wantContentType string
+		wantMetadata    map[string]string
+	}{
+		{
+			name:            "copy",
+			wantDirective:   types.MetadataDirectiveCopy,
+			wantContentType: "text/plain; charset=utf-8",
+			wantMetadata:    map[string]string{"source": "crm"},
+		},
+		{
+			name:            "replace_content_type",
+			opts:            []CopyOption{WithReplaceContentType("text/csv")},
+			wantDirective:   types.MetadataDirectiveReplace,
+			wantContentType: "text/csv",
+		},
+		{
+			name:            "replace_metadata",
+			opts:            []CopyOption{WithReplaceContentType("text/csv"), WithReplaceMetadata(map[string]string{"tier": "cold"})},
+			wantDirective:   types.MetadataDirectiveReplace,
+			wantContentType: "text/csv",
+			wantMetadata:    map[string]string{"tier": "cold"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.txt", strings.NewReader("id\n1\n"),
+				WithMetadata(map[string]string{"source": "crm"}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = client.CopyObject(context.Background(), "bucket", "raw/data.txt", "bucket", "cold/data.txt", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := fake.copyInputs[0].MetadataDirective; got != tt.wantDirective {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.wantDirective)
+			}
+
+			info, err := client.HeadObject(context.Background(), "bucket", "cold/data.txt")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if info.ContentType != tt.wantContentType || !reflect.DeepEqual(info.Metadata, tt.wantMetadata) {
+				t.Errorf("actual `%v %v` \n expected `%v %v`", info.ContentType, info.Metadata, tt.wantContentType, tt.wantMetadata)
+			}
+		})
+	}
+}
+
+func TestClient_CopyObject_multipart(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/big.bin", []byte("large content"))
+
+	source := fake.objects["src"]["raw/big.bin"]
+	source.contentType = "application/octet-stream"
+	source.cacheControl = "max-age=3600"
+	source.metadata = map[string]string{"origin": "export"}
+	source.tags = map[string]string{"retention": "long"}
+	source.checksumSHA256 = "checksum"
+	fake.objects["src"]["raw/big.bin"] = source
+
+	// The source is reported larger than a single CopyObject request can copy.
+	fake.headSizeDelta = maxCopyObjectSize
+
+	client := newTestClient(t, fake)
+
+	if err := client.CopyObject(context.Background(), "src", "raw/big.bin", "dst", "archive/big.bin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.copyInputs) != 0 {
+		t.Errorf("actual `%v` CopyObject calls \n expected `0`", len(fake.copyInputs))
+	}
+
+	copied, ok := fake.get("dst", "archive/big.bin")
+	if !ok {
+		t.Fatal("object `archive/big.bin` was not copied")
+	}
+
+	if string(copied.body) != "large content" {
+		t.Errorf("actual `%s` \n expected `large content`", copied.body)
+	}
+
+	if copied.contentType != source.contentType || copied.cacheControl != source.cacheControl {
+		t.Errorf("actual `%v %v` \n expected `%v %v`", copied.contentType, copied.cacheControl, source.contentType, source.cacheControl)
+	}
+
+	if !reflect.DeepEqual(copied.metadata, source.metadata) || !reflect.DeepEqual(copied.tags, source.tags) {
+		t.Errorf("actual `%v %v` \n expected `%v %v`", copied.metadata, copied.tags, source.metadata, source.tags)
+	}
+
+	// The copy is checksummed with the algorithm of the source.
+	if !strings.HasSuffix(copied.checksumSHA256, "-11") {
+		t.Errorf("actual checksum `%v` \n expected a SHA256 checksum of 11 parts", copied.checksumSHA256)
+	}
+}
+
+// emptyPartCopyS3 responds to UploadPartCopy without a copy part result.
+type emptyPartCopyS3 struct {
+	*fakeS3
+}
+
+func (f emptyPartCopyS3) UploadPartCopy(context.Context, *s3.UploadPartCopyInput, ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	return &s3.UploadPartCopyOutput{}, nil
+}
+
+func TestClient_CopyObject_emptyPartResult(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("src", "raw/big.bin", []byte("large content"))
+	fake.headSizeDelta = maxCopyObjectSize
+
+	client := newTestClient(t, emptyPartCopyS3{fakeS3: fake})
+
+	err := client.CopyObject(context.Background(), "src", "raw/big.bin", "dst", "archive/big.bin")
+
+	var sdkErr SDKError
+	if !errors.As(err, &sdkErr) {
+		t.Errorf("actual error `%v` \n expected SDKError", err)
+	}
+
+	if _, ok := fake.get("dst", "archive/big.bin"); ok {
+		t.Error("object `archive/big.bin` must not be copied")
+	}
+}
+
+func TestClient_CopyObject_sourceNotFound(t *testing.T) {
+	client := newTestClient(t, newFakeS3())
+
+	err := client.CopyObject(context.Background(), "src", "raw/missing.bin", "dst", "archive/missing.bin")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+}
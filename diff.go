@@ -0,0 +1,78 @@
+package s3utils
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DiffResult holds the differences between two prefixes found by DiffPrefixes.
+// Keys are relative to the prefixes and sorted.
+type DiffResult struct {
+	OnlyInA []string `json:"only_in_a"`
+	OnlyInB []string `json:"only_in_b"`
+	// Changed holds the keys present under both prefixes whose objects differ in size or ETag.
+	Changed []string `json:"changed"`
+}
+
+// DiffPrefixes compares the objects under prefixA in bucketA with the objects under prefixB in bucketB
+// by their keys relative to the prefixes.
+func (s *Client) DiffPrefixes(ctx context.Context, bucketA string, prefixA string, bucketB string, prefixB string) (DiffResult, error) {
+	if bucketA == "" || bucketB == "" {
+		return DiffResult{}, NewValidationError("bucket name is empty")
+	}
+
+	objectsA, err := s.listRelative(ctx, bucketA, prefixA)
+	if err != nil {
+		return DiffResult{}, err
+	}
+
+	objectsB, err := s.listRelative(ctx, bucketB, prefixB)
+	if err != nil {
+		return DiffResult{}, err
+	}
+
+	var result DiffResult
+
+	for key, objectA := range objectsA {
+		objectB, ok := objectsB[key]
+
+		switch {
+		case !ok:
+			result.OnlyInA = append(result.OnlyInA, key)
+		case objectA.Size != objectB.Size || objectA.ETag != objectB.ETag:
+			result.Changed = append(result.Changed, key)
+		}
+	}
+
+	for key := range objectsB {
+		if _, ok := objectsA[key]; !ok {
+			result.OnlyInB = append(result.OnlyInB, key)
+		}
+	}
+
+	slices.Sort(result.OnlyInA)
+	slices.Sort(result.OnlyInB)
+	slices.Sort(result.Changed)
+
+	return result, nil
+}
+
+// listRelative returns the objects under the prefix by their keys relative to the prefix.
+func (s *Client) listRelative(ctx context.Context, bucketName string, prefix string) (map[string]ObjectInfo, error) {
+	objects := make(map[string]ObjectInfo)
+
+	err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		info := objectInfoFromObject(object)
+		objects[strings.TrimPrefix(info.Key, prefix)] = info
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
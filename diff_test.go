@@ -0,0 +1,34 @@
+package s3utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestClient_DiffPrefixes(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("source", "raw/same.json", []byte("1"))
+	fake.put("source", "raw/changed.json", []byte("1"))
+	fake.put("source", "raw/nested/only_a.json", []byte("1"))
+	fake.put("replica", "backup/raw/same.json", []byte("1"))
+	fake.put("replica", "backup/raw/changed.json", []byte("2"))
+	fake.put("replica", "backup/raw/only_b.json", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	got, err := client.DiffPrefixes(context.Background(), "source", "raw/", "replica", "backup/raw/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := DiffResult{
+		OnlyInA: []string{"nested/only_a.json"},
+		OnlyInB: []string{"only_b.json"},
+		Changed: []string{"changed.json"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actual `%+v` \n expected `%+v`", got, want)
+	}
+}
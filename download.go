@@ -0,0 +1,619 @@
+package s3utils
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultMaxObjectBytes is the default limit of the object size read into memory by GetObjectBytes.
+const DefaultMaxObjectBytes = 32 * 1024 * 1024
+
+// GetObjectBytes returns the object content. It is meant for small objects such as config files,
+// so objects larger than the limit set with WithMaxSize are rejected with ErrObjectTooLarge.
+// A missing object results in an error matching ErrObjectNotFound.
+func (s *Client) GetObjectBytes(ctx context.Context, bucketName string, key string, opts ...DownloadOption) ([]byte, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, NewValidationError("key is empty")
+	}
+
+	key = strings.Trim(key, "/")
+
+	o := newDownloadOptions(opts)
+	if o.maxSize <= 0 {
+		return nil, NewValidationError("max size must be positive")
+	}
+
+	result, err := s.client.GetObject(ctx, newGetObjectInput(bucketName, key, o))
+	if err != nil {
+		return nil, newGetObjectError("unable to get object", err)
+	}
+
+	defer result.Body.Close()
+
+	verify := verifyDownloadETag(result, o)
+
+	limited, err := newLimitedBody(result, o)
+	if err != nil {
+		return nil, err
+	}
+
+	defer limited.Close()
+
+	body, err := io.ReadAll(limited)
+	if errors.Is(err, ErrObjectTooLarge) {
+		return nil, NewSDKError("unable to read object", err)
+	}
+
+	if err != nil {
+		return nil, NewSDKError("unable to read S3 response body", err)
+	}
+
+	err = verify()
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// newLimitedBody returns the decoded body of the object read into memory, limited to the size set with WithMaxSize.
+// Objects whose length exceeds the limit are rejected with ErrObjectTooLarge before their body is read.
+func newLimitedBody(result *s3.GetObjectOutput, o downloadOptions) (*maxSizeReader, error) {
+	if aws.ToInt64(result.ContentLength) > o.maxSize {
+		return nil, NewSDKError("unable to read object", fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrObjectTooLarge, aws.ToInt64(result.ContentLength), o.maxSize))
+	}
+
+	reader, err := decodeBody(result, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &maxSizeReader{r: reader, n: o.maxSize}, nil
+}
+
+// maxSizeReader reads up to n bytes from r and fails with ErrObjectTooLarge once more bytes are available.
+// The first read error other than io.EOF is kept in err.
+type maxSizeReader struct {
+	r   io.ReadCloser
+	n   int64
+	err error
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	// Reading one byte over the limit detects objects of unknown or misreported length.
+	if int64(len(p)) > r.n+1 {
+		p = p[:r.n+1]
+	}
+
+	n, err := r.r.Read(p)
+	r.n -= int64(n)
+
+	if r.n < 0 {
+		r.err = fmt.Errorf("%w: the content exceeds the size limit", ErrObjectTooLarge)
+
+		return 0, r.err
+	}
+
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+
+	return n, err
+}
+
+func (r *maxSizeReader) Close() error {
+	return r.r.Close()
+}
+
+// PeekObject returns the first n bytes of the object using a ranged request.
+// Objects shorter than n bytes are returned whole.
+func (s *Client) PeekObject(ctx context.Context, bucketName string, key string, n int64) ([]byte, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, NewValidationError("key is empty")
+	}
+
+	if n <= 0 {
+		return nil, NewValidationError("number of bytes must be positive")
+	}
+
+	key = strings.Trim(key, "/")
+
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	if err != nil {
+		// S3 rejects any range of an empty object.
+		if isInvalidRange(err) {
+			return []byte{}, nil
+		}
+
+		return nil, newGetObjectError("unable to get object", err)
+	}
+
+	defer result.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(result.Body, n))
+	if err != nil {
+		return nil, NewSDKError("unable to read S3 response body", err)
+	}
+
+	return body, nil
+}
+
+// GetObjectRangeReader returns the body of the bytes from start to end inclusive of the object together with
+// the total size of the object, e.g. to proxy HTTP range requests. The range is truncated at the end of the object.
+// The caller is responsible for closing the returned body.
+func (s *Client) GetObjectRangeReader(ctx context.Context, bucketName string, key string, start int64, end int64) (io.ReadCloser, int64, error) {
+	if bucketName == "" {
+		return nil, 0, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, 0, NewValidationError("key is empty")
+	}
+
+	if start < 0 || end < start {
+		return nil, 0, NewValidationError("invalid range")
+	}
+
+	key = strings.Trim(key, "/")
+
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+	})
+	if err != nil {
+		return nil, 0, newGetObjectError("unable to get object", err)
+	}
+
+	size, err := objectSizeFromContentRange(result)
+	if err != nil {
+		result.Body.Close()
+
+		return nil, 0, NewSDKError("unable to get object size", err)
+	}
+
+	return result.Body, size, nil
+}
+
+// objectSizeFromContentRange returns the total size of the object from the Content-Range of a ranged response,
+// e.g. `bytes 0-99/1234`. A response without a Content-Range holds the whole object.
+func objectSizeFromContentRange(output *s3.GetObjectOutput) (int64, error) {
+	if output.ContentRange == nil {
+		return aws.ToInt64(output.ContentLength), nil
+	}
+
+	contentRange := aws.ToString(output.ContentRange)
+
+	_, size, ok := strings.Cut(contentRange, "/")
+	if !ok {
+		return 0, fmt.Errorf("malformed content range %q", contentRange)
+	}
+
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed content range %q: %w", contentRange, err)
+	}
+
+	return n, nil
+}
+
+// GetObjectWithHeaders returns the object body together with its response metadata.
+// The caller is responsible for closing the returned body. With WithDecompress the body is decompressed,
+// while the size of the metadata remains the stored size.
+//
+// Combined with WithIfNoneMatch or WithIfModifiedSince it supports polling:
+// the error matches ErrNotModified while the object is unchanged.
+func (s *Client) GetObjectWithHeaders(ctx context.Context, bucketName string, key string, opts ...DownloadOption) (io.ReadCloser, ObjectInfo, error) {
+	if bucketName == "" {
+		return nil, ObjectInfo{}, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, ObjectInfo{}, NewValidationError("key is empty")
+	}
+
+	key = strings.Trim(key, "/")
+
+	o := newDownloadOptions(opts)
+	if o.verifyETag {
+		return nil, ObjectInfo{}, NewValidationError("ETag verification is not supported by GetObjectWithHeaders")
+	}
+
+	result, err := s.client.GetObject(ctx, newGetObjectInput(bucketName, key, o))
+	if err != nil {
+		return nil, ObjectInfo{}, newGetObjectError("unable to get object", err)
+	}
+
+	reader, err := decodeBody(result, o)
+	if err != nil {
+		result.Body.Close()
+
+		return nil, ObjectInfo{}, err
+	}
+
+	return &decodedBody{ReadCloser: reader, body: result.Body}, objectInfoFromGetObject(key, result), nil
+}
+
+// decodedBody is the decoded reader of a response body, closing the decoder together with the body.
+type decodedBody struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	return errors.Join(b.ReadCloser.Close(), b.body.Close())
+}
+
+// GetObjectToWriter streams the object content into the writer, e.g. an http.ResponseWriter or a bytes.Buffer.
+func (s *Client) GetObjectToWriter(ctx context.Context, bucketName string, key string, w io.Writer, opts ...DownloadOption) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if w == nil {
+		return NewValidationError("writer is nil")
+	}
+
+	key = strings.Trim(key, "/")
+
+	o := newDownloadOptions(opts)
+
+	result, err := s.client.GetObject(ctx, newGetObjectInput(bucketName, key, o))
+	if err != nil {
+		return newGetObjectError("unable to get object", err)
+	}
+
+	defer result.Body.Close()
+
+	verify := verifyDownloadETag(result, o)
+
+	_, err = copyBody(ctx, w, result, o)
+	if err != nil {
+		return err
+	}
+
+	return verify()
+}
+
+// StreamObjectChunks reads the object content in chunks of chunkSize bytes and calls fn with each chunk in order.
+// Only the last chunk may be shorter. The chunk is only valid during the call, as its buffer is reused.
+// Streaming stops at the first error returned by fn, which is returned as is.
+func (s *Client) StreamObjectChunks(ctx context.Context, bucketName string, key string, chunkSize int, fn func(chunk []byte) error) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if chunkSize <= 0 {
+		return NewValidationError("chunk size must be positive")
+	}
+
+	if fn == nil {
+		return NewValidationError("chunk function is nil")
+	}
+
+	key = strings.Trim(key, "/")
+
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return newGetObjectError("unable to get object", err)
+	}
+
+	defer result.Body.Close()
+
+	buf := make([]byte, chunkSize)
+
+	for {
+		n, err := io.ReadFull(result.Body, buf)
+		if n > 0 {
+			if fnErr := fn(buf[:n]); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+
+		if err != nil {
+			return NewSDKError("unable to read S3 response body", err)
+		}
+	}
+}
+
+// GetObjectToPipe returns the read end of a pipe into which the object content is copied by a goroutine,
+// so the download overlaps with the processing of the content. Errors of the download, including a missing
+// object, are returned by the reads of the pipe. The caller must read the pipe to the end or close it.
+func (s *Client) GetObjectToPipe(ctx context.Context, bucketName string, key string) (*io.PipeReader, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, NewValidationError("key is empty")
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		pipeWriter.CloseWithError(s.GetObjectToWriter(ctx, bucketName, key, pipeWriter))
+	}()
+
+	return pipeReader, nil
+}
+
+// DownloadLargeObject downloads the object into the local file with parallel range requests, which is much faster
+// than GetObject for large objects. The part size and the number of parallel requests are set with WithPartSize
+// and WithConcurrency. The content is written as stored, so WithDecompress is not supported.
+func (s *Client) DownloadLargeObject(ctx context.Context, bucketName string, key string, localPath string, opts ...DownloadOption) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if localPath == "" {
+		return NewValidationError("local path is empty")
+	}
+
+	o := newDownloadOptions(opts)
+
+	if o.partSize < 0 {
+		return NewValidationError("part size must be positive")
+	}
+
+	if o.concurrency < 0 {
+		return NewValidationError("concurrency must be positive")
+	}
+
+	if o.decompress {
+		return NewValidationError("decompression is not supported by parallel downloads")
+	}
+
+	if o.verifyETag {
+		return NewValidationError("ETag verification is not supported by parallel downloads")
+	}
+
+	key = strings.Trim(key, "/")
+
+	downloader := manager.NewDownloader(s.client, func(d *manager.Downloader) {
+		if o.partSize > 0 {
+			d.PartSize = o.partSize
+		}
+
+		if o.concurrency > 0 {
+			d.Concurrency = o.concurrency
+		}
+	})
+
+	return writeFileAtomic(localPath, func(file *os.File) error {
+		_, err := downloader.Download(ctx, file, newGetObjectInput(bucketName, key, o))
+		if err != nil {
+			return newGetObjectError("unable to download object", err)
+		}
+
+		return nil
+	})
+}
+
+// DownloadToDir downloads the object like GetObject into the file at the path of the key under baseDir,
+// creating the missing directories, and returns the path of the file. Keys that would leave baseDir,
+// e.g. "../etc/passwd", are rejected with a ValidationError, so keys from untrusted sources can be downloaded safely.
+func (s *Client) DownloadToDir(ctx context.Context, bucketName string, key string, baseDir string, opts ...DownloadOption) (string, error) {
+	if bucketName == "" {
+		return "", NewValidationError("bucket name is empty")
+	}
+
+	if baseDir == "" {
+		return "", NewValidationError("base directory is empty")
+	}
+
+	localPath, err := localObjectPath(baseDir, key)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(localPath), 0o755)
+	if err != nil {
+		return "", NewSDKError("unable to create directory", err)
+	}
+
+	err = s.GetObject(ctx, bucketName, key, localPath, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return localPath, nil
+}
+
+// localObjectPath returns the path of the key under baseDir. It rejects keys that don't name a file inside baseDir,
+// such as keys with ".." elements, which are not cleaned away because S3 doesn't resolve them either.
+func localObjectPath(baseDir string, key string) (string, error) {
+	key = strings.Trim(key, "/")
+	if key == "" {
+		return "", NewValidationError("key is empty")
+	}
+
+	rel := filepath.FromSlash(key)
+	if !filepath.IsLocal(rel) || slices.Contains(strings.Split(key, "/"), "..") || filepath.Clean(rel) == "." {
+		return "", NewValidationError("key is not a safe local path: " + key)
+	}
+
+	return filepath.Join(baseDir, rel), nil
+}
+
+// newGetObjectInput creates the GetObject input with the fields configured by the download options.
+func newGetObjectInput(bucketName string, key string, o downloadOptions) *s3.GetObjectInput {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	}
+
+	if !o.ifModifiedSince.IsZero() {
+		input.IfModifiedSince = aws.Time(o.ifModifiedSince)
+	}
+
+	if o.ifNoneMatch != "" {
+		input.IfNoneMatch = aws.String(o.ifNoneMatch)
+	}
+
+	return input
+}
+
+// copyBody copies the response body into the writer and returns the number of written bytes.
+// The copy stops once the context is done.
+func copyBody(ctx context.Context, w io.Writer, output *s3.GetObjectOutput, o downloadOptions) (int64, error) {
+	reader, err := decodeBody(output, o)
+	if err != nil {
+		return 0, err
+	}
+
+	defer reader.Close()
+
+	n, err := io.Copy(w, &contextReader{ctx: ctx, r: reader})
+	if err != nil {
+		return n, NewSDKError("unable to copy S3 response body", err)
+	}
+
+	return n, nil
+}
+
+// contextReader fails the reads once the context is done, as the body reads of a response
+// that was already received don't observe the request context.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// verifyDownloadETag makes the response body hash the content read from it if WithDownloadETagVerification is set.
+// The returned function reads the rest of the body and compares the hash with the ETag.
+// The ETag is the MD5 of the stored content, so it is compared before decompression.
+func verifyDownloadETag(output *s3.GetObjectOutput, o downloadOptions) func() error {
+	if !o.verifyETag {
+		return func() error { return nil }
+	}
+
+	hash := md5.New()
+	output.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(output.Body, hash), output.Body}
+
+	return func() error {
+		// A decoder may stop before the end of the body, e.g. after the gzip footer or the JSON value.
+		_, err := io.Copy(io.Discard, output.Body)
+		if err != nil {
+			return NewSDKError("unable to read S3 response body", err)
+		}
+
+		if err := compareETag(aws.ToString(output.ETag), hash.Sum(nil)); err != nil {
+			return NewS3Error("unable to verify downloaded object", err)
+		}
+
+		return nil
+	}
+}
+
+// decodeBody returns the reader of the response body, decompressing it if requested by the download options.
+// Closing the reader releases the decompressor, while the response body is still closed by the caller.
+func decodeBody(output *s3.GetObjectOutput, o downloadOptions) (io.ReadCloser, error) {
+	if !o.decompress || aws.ToString(output.ContentEncoding) != gzipEncoding {
+		return io.NopCloser(output.Body), nil
+	}
+
+	reader, err := gzip.NewReader(output.Body)
+	if err != nil {
+		return nil, NewSDKError("unable to decompress S3 response body", err)
+	}
+
+	return reader, nil
+}
+
+// writeFileAtomic writes the file with write into a temporary file in the same directory and renames it
+// to path on success, so path never holds a partial file. On failure the temporary file is removed
+// and an existing file at path is left unchanged.
+func writeFileAtomic(path string, write func(file *os.File) error) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return NewSDKError("unable to create file", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	// CreateTemp creates the file readable by the owner only, unlike os.Create.
+	err = file.Chmod(0o644)
+	if err != nil {
+		return NewSDKError("unable to set file mode", err)
+	}
+
+	err = write(file)
+	if err != nil {
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return NewSDKError("unable to close file", err)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return NewSDKError("unable to rename file", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,911 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestClient_GetObjectWithHeaders(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := fake.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket:      aws.String("bucket"),
+		Key:         aws.String("raw/test.json"),
+		Body:        strings.NewReader(`{"a":1}`),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, _ := fake.get("bucket", "raw/test.json")
+
+	body, info, err := client.GetObjectWithHeaders(context.Background(), "bucket", "/raw/test.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer body.Close()
+
+	want := ObjectInfo{
+		Key:          "raw/test.json",
+		Size:         int64(len(object.body)),
+		ContentType:  "application/json",
+		ETag:         object.etag,
+		LastModified: object.lastModified,
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("actual `%v` \n expected `%v`", info, want)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(content, object.body) {
+		t.Errorf("actual `%s` \n expected `%s`", content, object.body)
+	}
+}
+
+func TestClient_GetObjectToWriter(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/test.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake)
+
+	var buf bytes.Buffer
+
+	err := client.GetObjectToWriter(context.Background(), "bucket", "raw/test.json", &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if buf.String() != `{"a":1}` {
+		t.Errorf("actual `%s` \n expected `%s`", buf.String(), `{"a":1}`)
+	}
+
+	err = client.GetObjectToWriter(context.Background(), "bucket", "raw/test.json", nil)
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_GetObjectBytes(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "config/app.json", []byte(`{"debug":true}`))
+
+	client := newTestClient(t, fake)
+
+	body, err := client.GetObjectBytes(context.Background(), "bucket", "config/app.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(body) != `{"debug":true}` {
+		t.Errorf("actual `%s` \n expected `%s`", body, `{"debug":true}`)
+	}
+
+	_, err = client.GetObjectBytes(context.Background(), "bucket", "config/missing.json")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+
+	_, err = client.GetObjectBytes(context.Background(), "bucket", "config/app.json", WithMaxSize(4))
+	if !errors.Is(err, ErrObjectTooLarge) {
+		t.Errorf("actual error `%v` \n expected ErrObjectTooLarge", err)
+	}
+}
+
+func TestClient_GetObjectRangeReader(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "video/clip.mp4", []byte("0123456789"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name  string
+		start int64
+		end   int64
+		want  string
+	}{
+		{
+			name:  "middle",
+			start: 2,
+			end:   5,
+			want:  "2345",
+		},
+		{
+			name:  "past_end",
+			start: 8,
+			end:   100,
+			want:  "89",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, size, err := client.GetObjectRangeReader(context.Background(), "bucket", "video/clip.mp4", tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer body.Close()
+
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("actual `%s` \n expected `%s`", got, tt.want)
+			}
+
+			if size != 10 {
+				t.Errorf("actual size `%v` \n expected `%v`", size, 10)
+			}
+		})
+	}
+
+	_, _, err := client.GetObjectRangeReader(context.Background(), "bucket", "video/clip.mp4", 5, 2)
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	_, _, err = client.GetObjectRangeReader(context.Background(), "bucket", "video/missing.mp4", 0, 1)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected `%v`", err, ErrObjectNotFound)
+	}
+}
+
+func TestClient_PeekObject(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.csv", []byte("id,name\n1,alpha\n2,beta\n"))
+	fake.put("bucket", "raw/small.csv", []byte("id"))
+	fake.put("bucket", "raw/empty.csv", []byte{})
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name string
+		key  string
+		n    int64
+		want string
+	}{
+		{
+			name: "prefix",
+			key:  "raw/data.csv",
+			n:    7,
+			want: "id,name",
+		},
+		{
+			name: "small_object",
+			key:  "raw/small.csv",
+			n:    512,
+			want: "id",
+		},
+		{
+			name: "empty_object",
+			key:  "raw/empty.csv",
+			n:    512,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.PeekObject(context.Background(), "bucket", tt.key, tt.n)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("actual `%s` \n expected `%s`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GetObjectWithHeaders_conditional(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "config/app.json", []byte(`{"debug":true}`))
+
+	client := newTestClient(t, fake)
+
+	body, info, err := client.GetObjectWithHeaders(context.Background(), "bucket", "config/app.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body.Close()
+
+	_, _, err = client.GetObjectWithHeaders(context.Background(), "bucket", "config/app.json", WithIfNoneMatch(info.ETag))
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("actual error `%v` \n expected ErrNotModified", err)
+	}
+
+	_, _, err = client.GetObjectWithHeaders(context.Background(), "bucket", "config/app.json", WithIfModifiedSince(info.LastModified))
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("actual error `%v` \n expected ErrNotModified", err)
+	}
+
+	fake.put("bucket", "config/app.json", []byte(`{"debug":false}`))
+
+	body, changed, err := client.GetObjectWithHeaders(context.Background(), "bucket", "config/app.json", WithIfNoneMatch(info.ETag))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer body.Close()
+
+	if changed.ETag == info.ETag {
+		t.Errorf("actual etag `%v` \n expected a new etag", changed.ETag)
+	}
+}
+
+func TestClient_HeadObject(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "config/app.json", []byte(`{"debug":true}`))
+
+	client := newTestClient(t, fake)
+
+	info, err := client.HeadObject(context.Background(), "bucket", "config/app.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, _ := fake.get("bucket", "config/app.json")
+	if info.Size != int64(len(object.body)) || info.ETag != object.etag || !info.LastModified.Equal(object.lastModified) {
+		t.Errorf("actual `%v` \n expected size `%v` etag `%v` last modified `%v`", info, len(object.body), object.etag, object.lastModified)
+	}
+
+	_, err = client.HeadObject(context.Background(), "bucket", "config/missing.json")
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+}
+
+func TestClient_ObjectStat(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "config/app.json", []byte(`{"debug":true}`))
+
+	client := newTestClient(t, fake)
+
+	exists, size, err := client.ObjectStat(context.Background(), "bucket", "config/app.json")
+	if err != nil || !exists || size != 14 {
+		t.Errorf("actual `%v` `%v` `%v` \n expected `true` `14` `<nil>`", exists, size, err)
+	}
+
+	exists, size, err = client.ObjectStat(context.Background(), "bucket", "config/missing.json")
+	if err != nil || exists || size != 0 {
+		t.Errorf("actual `%v` `%v` `%v` \n expected `false` `0` `<nil>`", exists, size, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err = client.ObjectStat(ctx, "bucket", "config/app.json")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("actual error `%v` \n expected context.Canceled", err)
+	}
+}
+
+func TestClient_StreamObjectChunks(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", []byte("abcdefghij"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name      string
+		chunkSize int
+		want      []string
+	}{
+		{
+			name:      "last_chunk_shorter",
+			chunkSize: 4,
+			want:      []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:      "exact_chunks",
+			chunkSize: 5,
+			want:      []string{"abcde", "fghij"},
+		},
+		{
+			name:      "single_chunk",
+			chunkSize: 64,
+			want:      []string{"abcdefghij"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			err := client.StreamObjectChunks(context.Background(), "bucket", "raw/data.bin", tt.chunkSize, func(chunk []byte) error {
+				got = append(got, string(chunk))
+
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+
+	err := client.StreamObjectChunks(context.Background(), "bucket", "raw/data.bin", 2, func([]byte) error {
+		calls++
+
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("actual error `%v` after %d calls \n expected `%v` after 1 call", err, calls, errStop)
+	}
+}
+
+func TestClient_GetObjectToPipe(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", content)
+
+	client := newTestClient(t, fake)
+
+	pipeReader, err := client.GetObjectToPipe(context.Background(), "bucket", "raw/data.bin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := io.ReadAll(pipeReader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Errorf("actual %d bytes \n expected %d bytes", len(got), len(content))
+	}
+}
+
+func TestClient_GetObjectToPipe_downloadError(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	pipeReader, err := client.GetObjectToPipe(context.Background(), "bucket", "raw/missing.bin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = io.ReadAll(pipeReader)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+}
+
+// cancelingReader cancels the context after the first read.
+type cancelingReader struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.cancel()
+
+	return n, err
+}
+
+func TestClient_GetObject_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", bytes.Repeat([]byte("0123456789"), 100000))
+	fake.wrapBody = func(r io.Reader) io.Reader {
+		return &cancelingReader{r: r, cancel: cancel}
+	}
+
+	client := newTestClient(t, fake)
+
+	localPath := filepath.Join(t.TempDir(), "data.bin")
+
+	err := client.GetObject(ctx, "bucket", "raw/data.bin", localPath)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("actual error `%v` \n expected context.Canceled", err)
+	}
+
+	_, err = os.Stat(localPath)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("actual stat error `%v` \n expected the partial file to be removed", err)
+	}
+}
+
+// failingReader returns an error after the first read.
+type failingReader struct {
+	r    io.Reader
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+
+	r.read = true
+
+	return r.r.Read(p)
+}
+
+func TestClient_GetObject_failedWrite(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", bytes.Repeat([]byte("0123456789"), 100000))
+	fake.wrapBody = func(r io.Reader) io.Reader {
+		return &failingReader{r: r}
+	}
+
+	client := newTestClient(t, fake)
+
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "data.bin")
+
+	err := os.WriteFile(localPath, []byte("previous"), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = client.GetObject(context.Background(), "bucket", "raw/data.bin", localPath)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(content) != "previous" {
+		t.Errorf("actual content `%s` \n expected the existing file to be left unchanged", content)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("actual %d files \n expected the temporary file to be removed", len(entries))
+	}
+}
+
+func TestClient_GetObjectWithResult(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", writeTestFile(t, "test.json", `{"a":1}`), "test.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, _ := fake.get("bucket", "raw/test.json")
+
+	localPath := filepath.Join(t.TempDir(), "test.json")
+
+	result, err := client.GetObjectWithResult(context.Background(), "bucket", "raw/test.json", localPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := GetObjectResult{
+		BytesWritten: int64(len(object.body)),
+		ContentType:  "application/json",
+		ETag:         object.etag,
+		LastModified: object.lastModified,
+	}
+	if result != want {
+		t.Errorf("actual `%v` \n expected `%v`", result, want)
+	}
+
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(content, object.body) {
+		t.Errorf("actual `%s` \n expected `%s`", content, object.body)
+	}
+}
+
+func TestClient_DownloadLargeObject(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4096+7)
+
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", content)
+
+	client := newTestClient(t, fake)
+
+	localPath := filepath.Join(t.TempDir(), "data.bin")
+
+	err := client.DownloadLargeObject(context.Background(), "bucket", "raw/data.bin", localPath, WithPartSize(4096), WithConcurrency(4))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Errorf("actual %d bytes \n expected %d bytes", len(got), len(content))
+	}
+
+	err = client.DownloadLargeObject(context.Background(), "bucket", "raw/missing.bin", localPath)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("actual error `%v` \n expected ErrObjectNotFound", err)
+	}
+}
+
+// throttledReader limits the throughput of a single response body to 64 KiB per millisecond,
+// like the bandwidth of a single connection.
+type throttledReader struct {
+	r io.Reader
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p[:min(len(p), 64<<10)])
+	time.Sleep(time.Millisecond)
+
+	return n, err
+}
+
+// benchmarkDownload downloads a 16 MiB object from a fake with a latency of 2ms per request
+// and a throughput limited per request.
+func benchmarkDownload(b *testing.B, download func(client *Client, localPath string) error) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.bin", bytes.Repeat([]byte("0123456789abcdef"), 1<<20))
+	fake.delay = 2 * time.Millisecond
+	fake.wrapBody = func(r io.Reader) io.Reader {
+		return &throttledReader{r: r}
+	}
+
+	client, err := NewClientWithAPI(fake, "us-east-1")
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	localPath := filepath.Join(b.TempDir(), "data.bin")
+
+	b.ResetTimer()
+
+	for range b.N {
+		if err := download(client, localPath); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func BenchmarkClient_GetObject(b *testing.B) {
+	benchmarkDownload(b, func(client *Client, localPath string) error {
+		return client.GetObject(context.Background(), "bucket", "raw/data.bin", localPath)
+	})
+}
+
+func BenchmarkClient_DownloadLargeObject(b *testing.B) {
+	benchmarkDownload(b, func(client *Client, localPath string) error {
+		return client.DownloadLargeObject(context.Background(), "bucket", "raw/data.bin", localPath, WithPartSize(1<<20), WithConcurrency(8))
+	})
+}
+
+func TestClient_GetObject_etagVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		wrapBody func(io.Reader) io.Reader
+		wantErr  error
+	}{
+		{
+			name: "intact",
+		},
+		{
+			name: "corrupted",
+			wrapBody: func(r io.Reader) io.Reader {
+				return io.MultiReader(r, strings.NewReader("garbage"))
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("bucket", "raw/data.csv", []byte("id,name\n1,alpha\n"))
+			fake.wrapBody = tt.wrapBody
+
+			client := newTestClient(t, fake)
+
+			localPath := filepath.Join(t.TempDir(), "data.csv")
+
+			err := client.GetObject(context.Background(), "bucket", "raw/data.csv", localPath, WithDownloadETagVerification())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+
+			_, statErr := os.Stat(localPath)
+			if exists := statErr == nil; exists != (tt.wantErr == nil) {
+				t.Errorf("actual file exists `%v` \n expected `%v`", exists, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestWithDownloadETagVerification_methods(t *testing.T) {
+	tests := []struct {
+		name     string
+		download func(client *Client) error
+	}{
+		{
+			name: "bytes",
+			download: func(client *Client) error {
+				_, err := client.GetObjectBytes(context.Background(), "bucket", "raw/data.json", WithDownloadETagVerification())
+
+				return err
+			},
+		},
+		{
+			name: "json",
+			download: func(client *Client) error {
+				var v map[string]int
+
+				return client.GetObjectJSON(context.Background(), "bucket", "raw/data.json", &v, WithDownloadETagVerification())
+			},
+		},
+		{
+			name: "writer",
+			download: func(client *Client) error {
+				return client.GetObjectToWriter(context.Background(), "bucket", "raw/data.json", io.Discard, WithDownloadETagVerification())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+			client := newTestClient(t, fake)
+
+			if err := tt.download(client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The decoder stops at the end of the JSON value, so the trailing bytes are only seen by the hash.
+			fake.wrapBody = func(r io.Reader) io.Reader {
+				return io.MultiReader(r, strings.NewReader(" "))
+			}
+
+			if err := tt.download(client); !errors.Is(err, ErrChecksumMismatch) {
+				t.Errorf("actual error `%v` \n expected `%v`", err, ErrChecksumMismatch)
+			}
+		})
+	}
+}
+
+func TestWithDownloadETagVerification_unsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		download func(client *Client) error
+	}{
+		{
+			name: "large_object",
+			download: func(client *Client) error {
+				return client.DownloadLargeObject(context.Background(), "bucket", "raw/data.csv", filepath.Join(t.TempDir(), "data.csv"), WithDownloadETagVerification())
+			},
+		},
+		{
+			name: "with_headers",
+			download: func(client *Client) error {
+				_, _, err := client.GetObjectWithHeaders(context.Background(), "bucket", "raw/data.csv", WithDownloadETagVerification())
+
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("bucket", "raw/data.csv", []byte("id\n1\n"))
+
+			client := newTestClient(t, fake)
+
+			err := tt.download(client)
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestClient_DownloadToDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "nested",
+			key:  "users/42/report.csv",
+			want: filepath.Join("users", "42", "report.csv"),
+		},
+		{
+			name: "leading_slash",
+			key:  "/users/42/report.csv",
+			want: filepath.Join("users", "42", "report.csv"),
+		},
+		{
+			name:    "parent",
+			key:     "../report.csv",
+			wantErr: true,
+		},
+		{
+			name:    "inner_parent",
+			key:     "users/../../report.csv",
+			wantErr: true,
+		},
+		{
+			name:    "resolved_parent",
+			key:     "users/../report.csv",
+			wantErr: true,
+		},
+		{
+			name:    "dot",
+			key:     "./",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.put("bucket", strings.Trim(tt.key, "/"), []byte("id\n1\n"))
+
+			client := newTestClient(t, fake)
+
+			root := t.TempDir()
+			baseDir := filepath.Join(root, "downloads")
+
+			localPath, err := client.DownloadToDir(context.Background(), "bucket", tt.key, baseDir)
+			if tt.wantErr {
+				var validationErr ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Errorf("actual error `%v` \n expected ValidationError", err)
+				}
+
+				if _, err := os.Stat(filepath.Join(root, "report.csv")); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("file was written outside the base directory: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := filepath.Join(baseDir, tt.want); localPath != want {
+				t.Errorf("actual `%v` \n expected `%v`", localPath, want)
+			}
+
+			content, err := os.ReadFile(localPath)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(content) != "id\n1\n" {
+				t.Errorf("actual `%s` \n expected `id\n1\n`", content)
+			}
+		})
+	}
+}
+
+func TestClient_GetObject_notFound(t *testing.T) {
+	const (
+		noSuchKeyBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`
+		accessDeniedBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`
+	)
+
+	tests := []struct {
+		name         string
+		response     fakeHTTPResponse
+		wantNotFound bool
+	}{
+		{
+			name:         "no_such_key",
+			response:     fakeHTTPResponse{status: http.StatusNotFound, body: noSuchKeyBody},
+			wantNotFound: true,
+		},
+		{
+			name:     "access_denied",
+			response: fakeHTTPResponse{status: http.StatusForbidden, body: accessDeniedBody},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newHTTPTestClient(t, &fakeHTTPClient{responses: []fakeHTTPResponse{tt.response}}, nil)
+
+			localPath := filepath.Join(t.TempDir(), "data.csv")
+
+			err := client.GetObject(context.Background(), "bucket", "raw/data.csv", localPath)
+
+			var s3Err S3Error
+			if !errors.As(err, &s3Err) {
+				t.Errorf("actual error `%v` \n expected S3Error", err)
+			}
+
+			if errors.Is(err, ErrObjectNotFound) != tt.wantNotFound {
+				t.Errorf("actual error `%v` \n expected ErrObjectNotFound `%v`", err, tt.wantNotFound)
+			}
+
+			if _, err := os.Stat(localPath); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("actual `%v` \n expected no local file", err)
+			}
+		})
+	}
+}
+
+func Test_maxSizeReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		n       int64
+		wantErr error
+	}{
+		{
+			name:    "within_limit",
+			content: "abcd",
+			n:       4,
+		},
+		{
+			name:    "over_limit",
+			content: "abcde",
+			n:       4,
+			wantErr: ErrObjectTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(&maxSizeReader{r: io.NopCloser(strings.NewReader(tt.content)), n: tt.n})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,50 @@
 package s3utils
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+var (
+	// ErrChecksumMismatch is returned when the checksum of an object doesn't match the expected one.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrObjectNotFound is returned when the requested object doesn't exist.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrObjectTooLarge is returned when an object exceeds the size allowed for reading it into memory.
+	ErrObjectTooLarge = errors.New("object too large")
+	// ErrNotModified is returned by conditional requests when the object has not changed.
+	ErrNotModified = errors.New("object not modified")
+	// ErrVerificationFailed is returned when an uploaded object doesn't match the uploaded content.
+	ErrVerificationFailed = errors.New("upload verification failed")
+	// ErrObjectLockNotEnabled is returned when object lock settings are used with a bucket without object lock.
+	ErrObjectLockNotEnabled = errors.New("object lock not enabled")
+	// ErrRetryBudgetExhausted is returned when a request fails after the retry budget set with WithRetryBudget is consumed.
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+	// ErrBucketNotFound is returned when a bucket required by the operation doesn't exist.
+	ErrBucketNotFound = errors.New("bucket not found")
+	// ErrPreconditionFailed is returned when the condition of a conditional write, e.g. WithIfMatch, is not met.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrObjectExists is returned by uploads with WithCreateOnly when the object already exists.
+	// The error also matches ErrPreconditionFailed.
+	ErrObjectExists = errors.New("object already exists")
+	// ErrInvalidJSON is returned when the object content can't be decoded as JSON or a value can't be encoded as JSON.
+	ErrInvalidJSON = errors.New("invalid JSON")
+	// ErrBucketAlreadyOwned is returned by CreateBucket when the bucket already exists and is owned by the caller.
+	ErrBucketAlreadyOwned = errors.New("bucket already owned by you")
+	// ErrBucketAlreadyExists is returned by CreateBucket when the bucket name is taken by another account.
+	ErrBucketAlreadyExists = errors.New("bucket already exists")
+	// ErrAccessDenied is returned by Ping when S3 rejects the credentials or denies the request.
+	ErrAccessDenied = errors.New("access denied")
+	// ErrUnreachable is returned by Ping when S3 can't be reached, e.g. on DNS or connection failures.
+	ErrUnreachable = errors.New("s3 unreachable")
+)
 
 type SDKError struct {
 	Msg string
@@ -55,3 +99,249 @@ func (e S3Error) Error() string {
 func (e S3Error) Unwrap() error {
 	return e.Err
 }
+
+type DeleteObjectError struct {
+	Key     string
+	Code    string
+	Message string
+}
+
+func (e DeleteObjectError) Error() string {
+	return fmt.Sprintf("unable to delete object. key: %s. code: %s. msg: %s.", e.Key, e.Code, e.Message)
+}
+
+type MultiDeleteError struct {
+	Errors []DeleteObjectError
+}
+
+func NewMultiDeleteError(errs []DeleteObjectError) MultiDeleteError {
+	return MultiDeleteError{
+		Errors: errs,
+	}
+}
+
+func (e MultiDeleteError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s (%s: %s)", err.Key, err.Code, err.Message))
+	}
+
+	return fmt.Sprintf("unable to delete %d objects: %s", len(e.Errors), strings.Join(msgs, ", "))
+}
+
+func (e MultiDeleteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// newGetObjectError wraps an error of a request for an object, marking missing objects with ErrObjectNotFound
+// and unchanged objects of conditional requests with ErrNotModified.
+func newGetObjectError(msg string, err error) S3Error {
+	switch {
+	case isNotFound(err):
+		err = fmt.Errorf("%w: %w", ErrObjectNotFound, err)
+	case isNotModified(err):
+		err = fmt.Errorf("%w: %w", ErrNotModified, err)
+	}
+
+	return NewS3Error(msg, err)
+}
+
+// newPutObjectError wraps an error of a request writing an object,
+// marking requests rejected for a bucket without object lock with ErrObjectLockNotEnabled
+// and conditional writes whose condition is not met with ErrPreconditionFailed.
+func newPutObjectError(msg string, err error) S3Error {
+	switch {
+	case isObjectLockNotEnabled(err):
+		err = fmt.Errorf("%w: %w", ErrObjectLockNotEnabled, err)
+	case isPreconditionFailed(err):
+		err = fmt.Errorf("%w: %w", ErrPreconditionFailed, err)
+	}
+
+	return NewS3Error(msg, err)
+}
+
+// newUploadError wraps an error of an upload like newPutObjectError, also marking uploads
+// with If-None-Match: * rejected for an existing object with ErrObjectExists.
+func newUploadError(msg string, err error, o uploadOptions) S3Error {
+	if o.ifNoneMatch != nil && isPreconditionFailed(err) {
+		err = fmt.Errorf("%w: %w", ErrObjectExists, err)
+	}
+
+	return newPutObjectError(msg, err)
+}
+
+// newCreateBucketError wraps an error of a request creating a bucket,
+// marking existing buckets with ErrBucketAlreadyOwned or ErrBucketAlreadyExists.
+func newCreateBucketError(msg string, err error) S3Error {
+	switch {
+	case isBucketAlreadyOwned(err):
+		err = fmt.Errorf("%w: %w", ErrBucketAlreadyOwned, err)
+	case isBucketAlreadyExists(err):
+		err = fmt.Errorf("%w: %w", ErrBucketAlreadyExists, err)
+	}
+
+	return NewS3Error(msg, err)
+}
+
+// newPingError wraps an error of a health check request,
+// marking rejected credentials with ErrAccessDenied and network failures with ErrUnreachable.
+func newPingError(msg string, err error) S3Error {
+	switch {
+	case isAccessDenied(err):
+		err = fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	case isRequestSendError(err):
+		err = fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+
+	return NewS3Error(msg, err)
+}
+
+// isNotFound reports whether err is an S3 error for a missing object.
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotFound", "NoSuchKey":
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBucketNotFound reports whether err is an S3 error for a missing bucket.
+func isBucketNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+
+	var noSuchBucket *types.NoSuchBucket
+
+	return errors.As(err, &noSuchBucket)
+}
+
+// isBucketAlreadyOwned reports whether err is an S3 error for creating a bucket the caller already owns.
+func isBucketAlreadyOwned(err error) bool {
+	var alreadyOwned *types.BucketAlreadyOwnedByYou
+	if errors.As(err, &alreadyOwned) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "BucketAlreadyOwnedByYou"
+}
+
+// isBucketAlreadyExists reports whether err is an S3 error for creating a bucket whose name is taken by another account.
+func isBucketAlreadyExists(err error) bool {
+	var alreadyExists *types.BucketAlreadyExists
+	if errors.As(err, &alreadyExists) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "BucketAlreadyExists"
+}
+
+// isNotModified reports whether err is an S3 304 Not Modified response to a conditional request.
+func isNotModified(err error) bool {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotModified {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NotModified"
+}
+
+// isPreconditionFailed reports whether err is an S3 412 Precondition Failed response to a conditional request.
+func isPreconditionFailed(err error) bool {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed"
+}
+
+// isObjectLockNotEnabled reports whether err is an S3 error for object lock settings
+// sent to a bucket without an object lock configuration.
+func isObjectLockNotEnabled(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.ErrorCode() {
+	case "ObjectLockConfigurationNotFoundError":
+		return true
+	case "InvalidRequest":
+		return strings.Contains(apiErr.ErrorMessage(), "Object Lock")
+	}
+
+	return false
+}
+
+// isInvalidRange reports whether err is an S3 error for a range that doesn't overlap the object.
+func isInvalidRange(err error) bool {
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange"
+}
+
+// isNoSuchUpload reports whether err is an S3 error for a multipart upload that was already completed or aborted.
+func isNoSuchUpload(err error) bool {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchUpload"
+}
+
+// isAccessDenied reports whether err is an S3 error for invalid credentials or a request denied by a policy.
+// Other 403 errors, e.g. RequestTimeTooSkewed, are not. HEAD responses have no body, so their 403 errors
+// only have the Forbidden code.
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.ErrorCode() {
+	case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken", "InvalidToken", "Forbidden":
+		return true
+	}
+
+	return false
+}
+
+// isRequestSendError reports whether err is a failure to send the request or to receive its response,
+// as opposed to an error response of S3.
+func isRequestSendError(err error) bool {
+	var sendErr *smithyhttp.RequestSendError
+
+	return errors.As(err, &sendErr)
+}
@@ -0,0 +1,347 @@
+package s3utils
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Names of the S3 operations used by the package, as accepted by WithOperationTimeouts.
+const (
+	OperationPutObject                       = "PutObject"
+	OperationGetObject                       = "GetObject"
+	OperationHeadObject                      = "HeadObject"
+	OperationGetObjectAttributes             = "GetObjectAttributes"
+	OperationGetObjectTagging                = "GetObjectTagging"
+	OperationCopyObject                      = "CopyObject"
+	OperationListObjectsV2                   = "ListObjectsV2"
+	OperationListObjectVersions              = "ListObjectVersions"
+	OperationDeleteObject                    = "DeleteObject"
+	OperationDeleteObjects                   = "DeleteObjects"
+	OperationCreateBucket                    = "CreateBucket"
+	OperationCreateMultipartUpload           = "CreateMultipartUpload"
+	OperationUploadPart                      = "UploadPart"
+	OperationUploadPartCopy                  = "UploadPartCopy"
+	OperationCompleteMultipartUpload         = "CompleteMultipartUpload"
+	OperationAbortMultipartUpload            = "AbortMultipartUpload"
+	OperationRestoreObject                   = "RestoreObject"
+	OperationPutObjectRetention              = "PutObjectRetention"
+	OperationPutBucketLifecycleConfiguration = "PutBucketLifecycleConfiguration"
+	OperationGetBucketLifecycleConfiguration = "GetBucketLifecycleConfiguration"
+	OperationHeadBucket                      = "HeadBucket"
+	OperationPutBucketLogging                = "PutBucketLogging"
+	OperationListMultipartUploads            = "ListMultipartUploads"
+	OperationGetBucketLocation               = "GetBucketLocation"
+	OperationListBuckets                     = "ListBuckets"
+)
+
+// OperationSelectObjectContent is the name of the SelectObjectContent operation reported to the logger and the observer.
+// It doesn't accept a timeout, as the records are streamed after the call returns.
+const OperationSelectObjectContent = "SelectObjectContent"
+
+var operations = []string{
+	OperationPutObject,
+	OperationGetObject,
+	OperationHeadObject,
+	OperationGetObjectAttributes,
+	OperationGetObjectTagging,
+	OperationCopyObject,
+	OperationListObjectsV2,
+	OperationListObjectVersions,
+	OperationDeleteObject,
+	OperationDeleteObjects,
+	OperationCreateBucket,
+	OperationCreateMultipartUpload,
+	OperationUploadPart,
+	OperationUploadPartCopy,
+	OperationCompleteMultipartUpload,
+	OperationAbortMultipartUpload,
+	OperationRestoreObject,
+	OperationPutObjectRetention,
+	OperationPutBucketLifecycleConfiguration,
+	OperationGetBucketLifecycleConfiguration,
+	OperationHeadBucket,
+	OperationPutBucketLogging,
+	OperationListMultipartUploads,
+	OperationGetBucketLocation,
+	OperationListBuckets,
+}
+
+// instrumentedAPI wraps an S3API and applies the client options that concern every operation.
+type instrumentedAPI struct {
+	api     *regionClients
+	options clientOptions
+}
+
+var _ S3API = (*instrumentedAPI)(nil)
+
+// start prepares the context of the operation. The returned function must be called with the operation error
+// once the operation is done.
+func (a *instrumentedAPI) start(ctx context.Context, operation string, bucketName *string, key *string) (context.Context, func(err error)) {
+	cancel := func() {}
+
+	if _, ok := ctx.Deadline(); !ok {
+		if timeout, ok := a.options.operationTimeouts[operation]; ok {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+	}
+
+	logger, observer := a.options.logger, a.options.observer
+	if logger != nil {
+		logger(LogLevelDebug, "s3 operation started", "operation", operation, "bucket", aws.ToString(bucketName), "key", aws.ToString(key))
+	}
+
+	startedAt := time.Now()
+
+	return ctx, func(err error) {
+		cancel()
+
+		duration := time.Since(startedAt)
+
+		if observer != nil {
+			observer.ObserveOperation(operation, duration, err)
+		}
+
+		if logger == nil {
+			return
+		}
+
+		if err != nil {
+			logger(LogLevelError, "s3 operation failed", "operation", operation, "bucket", aws.ToString(bucketName), "key", aws.ToString(key), "duration", duration, "error", err)
+
+			return
+		}
+
+		logger(LogLevelDebug, "s3 operation finished", "operation", operation, "bucket", aws.ToString(bucketName), "key", aws.ToString(key), "duration", duration)
+	}
+}
+
+// call runs the operation fn between start and done, using the API client of the operation region.
+func call[In any, Out any](ctx context.Context, a *instrumentedAPI, operation string, bucketName *string, key *string, fn func(S3API, context.Context, In, ...func(*s3.Options)) (Out, error), params In, optFns []func(*s3.Options)) (Out, error) {
+	params = withBucketOwner(a.options, params)
+
+	api, err := a.regionAPI(ctx, operation, bucketName)
+	if err != nil {
+		var zero Out
+
+		return zero, err
+	}
+
+	ctx, done := a.start(ctx, operation, bucketName, key)
+
+	output, err := fn(api, ctx, params, optFns...)
+	done(err)
+
+	return output, err
+}
+
+// withBucketOwner returns a copy of the input with the account ID set with WithExpectedBucketOwner
+// as the expected owner of the bucket and of the copy source bucket, or the input itself if it is not set.
+// The fields are set by name, as the input types share no interface.
+func withBucketOwner[In any](o clientOptions, params In) In {
+	if o.expectedBucketOwner == "" {
+		return params
+	}
+
+	input := reflect.New(reflect.TypeOf(params).Elem())
+	input.Elem().Set(reflect.ValueOf(params).Elem())
+
+	for _, name := range []string{"ExpectedBucketOwner", "ExpectedSourceBucketOwner"} {
+		if field := input.Elem().FieldByName(name); field.IsValid() {
+			field.Set(reflect.ValueOf(o.bucketOwner()))
+		}
+	}
+
+	return input.Interface().(In)
+}
+
+// regionAPI returns the API client of the region set with WithOperationRegion or, with WithBucketRegionDetection,
+// of the region of the bucket. Operations that don't act on an existing bucket use the default client.
+func (a *instrumentedAPI) regionAPI(ctx context.Context, operation string, bucketName *string) (S3API, error) {
+	region := operationRegion(ctx)
+
+	if region == "" && a.options.detectBucketRegion && bucketName != nil &&
+		operation != OperationCreateBucket && operation != OperationGetBucketLocation {
+		var err error
+
+		region, err = a.api.bucketRegion(*bucketName, func() (string, error) {
+			locationResp, err := a.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: bucketName})
+			if err != nil {
+				return "", err
+			}
+
+			return bucketRegionFromLocation(locationResp.LocationConstraint), nil
+		})
+		if err != nil {
+			// The operation itself reports the error, e.g. of a missing bucket, from the default region.
+			region = ""
+		}
+	}
+
+	return a.api.get(region)
+}
+
+func (a *instrumentedAPI) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	return call(ctx, a, OperationPutObject, params.Bucket, params.Key, S3API.PutObject, params, optFns)
+}
+
+func (a *instrumentedAPI) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if a.options.requesterPays {
+		input := *params
+		input.RequestPayer = types.RequestPayerRequester
+		params = &input
+	}
+
+	params = withBucketOwner(a.options, params)
+
+	api, err := a.regionAPI(ctx, OperationGetObject, params.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, done := a.start(ctx, OperationGetObject, params.Bucket, params.Key)
+
+	output, err := api.GetObject(ctx, params, optFns...)
+	if err != nil {
+		done(err)
+
+		return nil, err
+	}
+
+	// The body is read after the call returns, so the operation ends when the body is closed.
+	output.Body = &doneReadCloser{ReadCloser: output.Body, done: done}
+
+	return output, nil
+}
+
+func (a *instrumentedAPI) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if a.options.requesterPays {
+		input := *params
+		input.RequestPayer = types.RequestPayerRequester
+		params = &input
+	}
+
+	return call(ctx, a, OperationHeadObject, params.Bucket, params.Key, S3API.HeadObject, params, optFns)
+}
+
+func (a *instrumentedAPI) GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error) {
+	if a.options.requesterPays {
+		input := *params
+		input.RequestPayer = types.RequestPayerRequester
+		params = &input
+	}
+
+	return call(ctx, a, OperationGetObjectAttributes, params.Bucket, params.Key, S3API.GetObjectAttributes, params, optFns)
+}
+
+func (a *instrumentedAPI) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	return call(ctx, a, OperationGetObjectTagging, params.Bucket, params.Key, S3API.GetObjectTagging, params, optFns)
+}
+
+func (a *instrumentedAPI) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	return call(ctx, a, OperationCopyObject, params.Bucket, params.Key, S3API.CopyObject, params, optFns)
+}
+
+func (a *instrumentedAPI) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	if a.options.requesterPays {
+		input := *params
+		input.RequestPayer = types.RequestPayerRequester
+		params = &input
+	}
+
+	return call(ctx, a, OperationListObjectsV2, params.Bucket, nil, S3API.ListObjectsV2, params, optFns)
+}
+
+func (a *instrumentedAPI) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	return call(ctx, a, OperationListObjectVersions, params.Bucket, nil, S3API.ListObjectVersions, params, optFns)
+}
+
+func (a *instrumentedAPI) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return call(ctx, a, OperationDeleteObject, params.Bucket, params.Key, S3API.DeleteObject, params, optFns)
+}
+
+func (a *instrumentedAPI) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	return call(ctx, a, OperationUploadPart, params.Bucket, params.Key, S3API.UploadPart, params, optFns)
+}
+
+func (a *instrumentedAPI) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	return call(ctx, a, OperationDeleteObjects, params.Bucket, nil, S3API.DeleteObjects, params, optFns)
+}
+
+func (a *instrumentedAPI) CreateBucket(ctx context.Context, params *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	return call(ctx, a, OperationCreateBucket, params.Bucket, nil, S3API.CreateBucket, params, optFns)
+}
+
+func (a *instrumentedAPI) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	return call(ctx, a, OperationCreateMultipartUpload, params.Bucket, params.Key, S3API.CreateMultipartUpload, params, optFns)
+}
+
+func (a *instrumentedAPI) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	return call(ctx, a, OperationUploadPartCopy, params.Bucket, params.Key, S3API.UploadPartCopy, params, optFns)
+}
+
+func (a *instrumentedAPI) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	return call(ctx, a, OperationCompleteMultipartUpload, params.Bucket, params.Key, S3API.CompleteMultipartUpload, params, optFns)
+}
+
+func (a *instrumentedAPI) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	return call(ctx, a, OperationAbortMultipartUpload, params.Bucket, params.Key, S3API.AbortMultipartUpload, params, optFns)
+}
+
+func (a *instrumentedAPI) RestoreObject(ctx context.Context, params *s3.RestoreObjectInput, optFns ...func(*s3.Options)) (*s3.RestoreObjectOutput, error) {
+	return call(ctx, a, OperationRestoreObject, params.Bucket, params.Key, S3API.RestoreObject, params, optFns)
+}
+
+func (a *instrumentedAPI) PutObjectRetention(ctx context.Context, params *s3.PutObjectRetentionInput, optFns ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error) {
+	return call(ctx, a, OperationPutObjectRetention, params.Bucket, params.Key, S3API.PutObjectRetention, params, optFns)
+}
+
+func (a *instrumentedAPI) PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	return call(ctx, a, OperationPutBucketLifecycleConfiguration, params.Bucket, nil, S3API.PutBucketLifecycleConfiguration, params, optFns)
+}
+
+func (a *instrumentedAPI) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	return call(ctx, a, OperationGetBucketLifecycleConfiguration, params.Bucket, nil, S3API.GetBucketLifecycleConfiguration, params, optFns)
+}
+
+func (a *instrumentedAPI) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	return call(ctx, a, OperationHeadBucket, params.Bucket, nil, S3API.HeadBucket, params, optFns)
+}
+
+func (a *instrumentedAPI) PutBucketLogging(ctx context.Context, params *s3.PutBucketLoggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketLoggingOutput, error) {
+	return call(ctx, a, OperationPutBucketLogging, params.Bucket, nil, S3API.PutBucketLogging, params, optFns)
+}
+
+func (a *instrumentedAPI) ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	return call(ctx, a, OperationListMultipartUploads, params.Bucket, nil, S3API.ListMultipartUploads, params, optFns)
+}
+
+func (a *instrumentedAPI) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	return call(ctx, a, OperationGetBucketLocation, params.Bucket, nil, S3API.GetBucketLocation, params, optFns)
+}
+
+func (a *instrumentedAPI) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	return call(ctx, a, OperationListBuckets, nil, nil, S3API.ListBuckets, params, optFns)
+}
+
+func (a *instrumentedAPI) SelectObjectContent(ctx context.Context, params *s3.SelectObjectContentInput, optFns ...func(*s3.Options)) (*s3.SelectObjectContentOutput, error) {
+	return call(ctx, a, OperationSelectObjectContent, params.Bucket, params.Key, S3API.SelectObjectContent, params, optFns)
+}
+
+// doneReadCloser calls done when the wrapped body is closed.
+type doneReadCloser struct {
+	io.ReadCloser
+	done func(err error)
+}
+
+func (r *doneReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.done(err)
+
+	return err
+}
@@ -0,0 +1,381 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestWithOperationTimeouts(t *testing.T) {
+	fake := newFakeS3()
+	fake.delay = 50 * time.Millisecond
+	fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake, WithOperationTimeouts(map[string]time.Duration{
+		OperationHeadObject: 10 * time.Millisecond,
+		OperationPutObject:  time.Second,
+	}))
+
+	// CopyObject starts with a HeadObject of the source.
+	err := client.CopyObject(context.Background(), "bucket", "raw/data.json", "bucket", "raw/copy.json")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("actual error `%v` \n expected context.DeadlineExceeded", err)
+	}
+
+	_, err = client.UploadReader(context.Background(), "bucket", "raw", "upload.json", strings.NewReader(`{"b":2}`))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// A deadline of the incoming context takes precedence.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err = client.CopyObject(ctx, "bucket", "raw/data.json", "bucket", "raw/copy.json")
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWithOperationTimeouts_validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts map[string]time.Duration
+	}{
+		{
+			name:     "unknown_operation",
+			timeouts: map[string]time.Duration{"Upload": time.Second},
+		},
+		{
+			name:     "non_positive_timeout",
+			timeouts: map[string]time.Duration{OperationHeadObject: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClientWithAPI(newFakeS3(), "us-east-1", WithOperationTimeouts(tt.timeouts))
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+type logRecord struct {
+	level   string
+	msg     string
+	keyvals []any
+}
+
+func TestWithLogger(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	var records []logRecord
+
+	client := newTestClient(t, fake, WithLogger(func(level string, msg string, keyvals ...any) {
+		records = append(records, logRecord{level: level, msg: msg, keyvals: keyvals})
+	}))
+
+	if _, err := client.HeadObject(context.Background(), "bucket", "raw/data.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.HeadObject(context.Background(), "bucket", "raw/missing.json"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []struct {
+		level string
+		msg   string
+	}{
+		{level: LogLevelDebug, msg: "s3 operation started"},
+		{level: LogLevelDebug, msg: "s3 operation finished"},
+		{level: LogLevelDebug, msg: "s3 operation started"},
+		{level: LogLevelError, msg: "s3 operation failed"},
+	}
+
+	if len(records) != len(want) {
+		t.Fatalf("actual `%v` records \n expected `%v`", len(records), len(want))
+	}
+
+	for i, record := range records {
+		if record.level != want[i].level || record.msg != want[i].msg {
+			t.Errorf("record %d: actual `%v %v` \n expected `%v %v`", i, record.level, record.msg, want[i].level, want[i].msg)
+		}
+
+		if record.keyvals[0] != "operation" || record.keyvals[1] != OperationHeadObject || record.keyvals[3] != "bucket" {
+			t.Errorf("record %d: actual keyvals `%v`", i, record.keyvals)
+		}
+	}
+
+	if records[1].keyvals[5] != "raw/data.json" || records[1].keyvals[6] != "duration" {
+		t.Errorf("actual keyvals `%v` \n expected key and duration", records[1].keyvals)
+	}
+}
+
+func TestWithLogger_nil(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake, WithLogger(nil))
+
+	if _, err := client.HeadObject(context.Background(), "bucket", "raw/data.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type observation struct {
+	operation string
+	failed    bool
+}
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (o *recordingObserver) ObserveOperation(operation string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.observations = append(o.observations, observation{operation: operation, failed: err != nil})
+}
+
+func TestWithObserver(t *testing.T) {
+	fake := newFakeS3()
+	observer := &recordingObserver{}
+
+	client := newTestClient(t, fake, WithObserver(observer))
+
+	filePath := writeTestFile(t, "data.json", `{"a":1}`)
+
+	if _, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.GetObjectToWriter(context.Background(), "bucket", "raw/data.json", io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.ListObjects(context.Background(), "bucket", "raw/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/data.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.GetObjectBytes(context.Background(), "bucket", "raw/data.json"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	want := []observation{
+		{operation: OperationPutObject},
+		{operation: OperationGetObject},
+		{operation: OperationListObjectsV2},
+		{operation: OperationDeleteObject},
+		{operation: OperationGetObject, failed: true},
+	}
+
+	if len(observer.observations) != len(want) {
+		t.Fatalf("actual `%v` \n expected `%v`", observer.observations, want)
+	}
+
+	for i := range want {
+		if observer.observations[i] != want[i] {
+			t.Errorf("observation %d: actual `%v` \n expected `%v`", i, observer.observations[i], want[i])
+		}
+	}
+}
+
+// payerRecordingS3 records the request payer of the read requests.
+type payerRecordingS3 struct {
+	*fakeS3
+
+	payers []types.RequestPayer
+}
+
+func (f *payerRecordingS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.payers = append(f.payers, params.RequestPayer)
+
+	return f.fakeS3.GetObject(ctx, params, optFns...)
+}
+
+func (f *payerRecordingS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.payers = append(f.payers, params.RequestPayer)
+
+	return f.fakeS3.HeadObject(ctx, params, optFns...)
+}
+
+func (f *payerRecordingS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.payers = append(f.payers, params.RequestPayer)
+
+	return f.fakeS3.ListObjectsV2(ctx, params, optFns...)
+}
+
+func TestWithRequesterPays(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want types.RequestPayer
+	}{
+		{
+			name: "requester_pays",
+			opts: []Option{WithRequesterPays()},
+			want: types.RequestPayerRequester,
+		},
+		{
+			name: "owner_pays",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &payerRecordingS3{fakeS3: newFakeS3()}
+			fake.put("bucket", "raw/data.json", []byte(`{}`))
+
+			client := newTestClient(t, fake, tt.opts...)
+
+			if _, err := client.GetObjectBytes(context.Background(), "bucket", "raw/data.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := client.HeadObject(context.Background(), "bucket", "raw/data.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := client.ListObjects(context.Background(), "bucket", "raw/"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := []types.RequestPayer{tt.want, tt.want, tt.want}
+			if !slices.Equal(fake.payers, want) {
+				t.Errorf("actual `%v` \n expected `%v`", fake.payers, want)
+			}
+		})
+	}
+}
+
+// ownerRecordingS3 records the expected bucket owner of the requests.
+type ownerRecordingS3 struct {
+	*fakeS3
+	owners []string
+}
+
+func (f *ownerRecordingS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.owners = append(f.owners, aws.ToString(params.ExpectedBucketOwner))
+
+	return f.fakeS3.PutObject(ctx, params, optFns...)
+}
+
+func (f *ownerRecordingS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.owners = append(f.owners, aws.ToString(params.ExpectedBucketOwner))
+
+	return f.fakeS3.GetObject(ctx, params, optFns...)
+}
+
+func (f *ownerRecordingS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.owners = append(f.owners, aws.ToString(params.ExpectedBucketOwner))
+
+	return f.fakeS3.DeleteObject(ctx, params, optFns...)
+}
+
+func TestWithExpectedBucketOwner(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "expected_owner",
+			opts: []Option{WithExpectedBucketOwner("123456789012")},
+			want: "123456789012",
+		},
+		{
+			name: "any_owner",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &ownerRecordingS3{fakeS3: newFakeS3()}
+			client := newTestClient(t, fake, tt.opts...)
+
+			if _, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{}`)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := client.GetObjectBytes(context.Background(), "bucket", "raw/data.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := client.DeleteObject(context.Background(), "bucket", "raw/data.json"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := []string{tt.want, tt.want, tt.want}
+			if !slices.Equal(fake.owners, want) {
+				t.Errorf("actual `%v` \n expected `%v`", fake.owners, want)
+			}
+		})
+	}
+}
+
+func TestWithExpectedBucketOwner_headers(t *testing.T) {
+	httpClient := &fakeHTTPClient{
+		responses: []fakeHTTPResponse{
+			{status: http.StatusOK},
+			{status: http.StatusOK, body: `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`},
+		},
+	}
+
+	client := newHTTPTestClient(t, httpClient, nil, WithExpectedBucketOwner("123456789012"))
+
+	if _, err := client.BucketExists(context.Background(), "bucket"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.copyObject(context.Background(), "src", "raw/a.json", "bucket", "raw/b.json", 1, copyOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(httpClient.requests) != 2 {
+		t.Fatalf("actual `%v` requests \n expected `2`", len(httpClient.requests))
+	}
+
+	if owner := httpClient.requests[0].Header.Get("X-Amz-Expected-Bucket-Owner"); owner != "123456789012" {
+		t.Errorf("head bucket: actual `%v` \n expected `123456789012`", owner)
+	}
+
+	copyHeader := httpClient.requests[1].Header
+	if copyHeader.Get("X-Amz-Expected-Bucket-Owner") != "123456789012" || copyHeader.Get("X-Amz-Source-Expected-Bucket-Owner") != "123456789012" {
+		t.Errorf("copy: actual `%v` \n expected both owners `123456789012`", copyHeader)
+	}
+}
+
+func TestWithExpectedBucketOwner_validation(t *testing.T) {
+	for _, accountID := range []string{"12345678901", "12345678901a", "account"} {
+		_, err := newClientOptions([]Option{WithExpectedBucketOwner(accountID)})
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%v: actual error `%v` \n expected ValidationError", accountID, err)
+		}
+	}
+}
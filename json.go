@@ -0,0 +1,111 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const jsonContentType = "application/json"
+
+// GetObjectJSON decodes the JSON object content into v, which must be a pointer.
+// Objects larger than the limit set with WithMaxSize are rejected with ErrObjectTooLarge.
+// A download failure results in an S3Error, while content that can't be decoded into v results
+// in an error matching ErrInvalidJSON.
+func (s *Client) GetObjectJSON(ctx context.Context, bucketName string, key string, v any, opts ...DownloadOption) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if v == nil {
+		return NewValidationError("value is nil")
+	}
+
+	key = strings.Trim(key, "/")
+
+	o := newDownloadOptions(opts)
+	if o.maxSize <= 0 {
+		return NewValidationError("max size must be positive")
+	}
+
+	result, err := s.client.GetObject(ctx, newGetObjectInput(bucketName, key, o))
+	if err != nil {
+		return newGetObjectError("unable to get object", err)
+	}
+
+	defer result.Body.Close()
+
+	verify := verifyDownloadETag(result, o)
+
+	limited, err := newLimitedBody(result, o)
+	if err != nil {
+		return err
+	}
+
+	defer limited.Close()
+
+	err = json.NewDecoder(limited).Decode(v)
+	if limited.err != nil {
+		return NewSDKError("unable to read S3 response body", limited.err)
+	}
+
+	if err != nil {
+		return NewSDKError("unable to decode object", fmt.Errorf("%w: %w", ErrInvalidJSON, err))
+	}
+
+	return verify()
+}
+
+// UploadJSON marshals v to JSON and uploads it to the directory under the filename
+// with the application/json content type. Use WithPrettyJSON to indent the content.
+func (s *Client) UploadJSON(ctx context.Context, bucketName string, directory string, filename string, v any, opts ...UploadOption) (UploadInfo, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return UploadInfo{}, err
+	}
+
+	if directory == "" {
+		return UploadInfo{}, NewValidationError("directory is empty")
+	}
+
+	if filename == "" {
+		return UploadInfo{}, NewValidationError("filename is empty")
+	}
+
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	if o.contentLength != nil {
+		return UploadInfo{}, NewValidationError("content length is supported by UploadReader only")
+	}
+
+	if err := o.validateFilenameUpload("UploadJSON"); err != nil {
+		return UploadInfo{}, err
+	}
+
+	var body []byte
+	if o.prettyJSON {
+		body, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		body, err = json.Marshal(v)
+	}
+
+	if err != nil {
+		return UploadInfo{}, NewSDKError("unable to encode value", fmt.Errorf("%w: %w", ErrInvalidJSON, err))
+	}
+
+	o.contentType = jsonContentType
+	// The encoded value is sent in a single request with its length.
+	o.contentLength = aws.Int64(int64(len(body)))
+
+	return s.putStream(ctx, bucketName, o.objectKey(directory, filename), bytes.NewReader(body), o)
+}
@@ -0,0 +1,132 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testConfig struct {
+	Name    string   `json:"name"`
+	Retries int      `json:"retries"`
+	Tags    []string `json:"tags"`
+}
+
+func TestClient_GetObjectJSON(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "config/app.json", []byte(`{"name":"loader","retries":3,"tags":["a","b"]}`))
+	fake.put("bucket", "config/broken.json", []byte(`{"name":`))
+
+	client := newTestClient(t, fake)
+
+	var config testConfig
+
+	err := client.GetObjectJSON(context.Background(), "bucket", "config/app.json", &config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.Name != "loader" || config.Retries != 3 || len(config.Tags) != 2 {
+		t.Errorf("actual `%+v` \n expected `%+v`", config, testConfig{Name: "loader", Retries: 3, Tags: []string{"a", "b"}})
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    []DownloadOption
+		wantErr error
+	}{
+		{
+			name:    "invalid_json",
+			key:     "config/broken.json",
+			wantErr: ErrInvalidJSON,
+		},
+		{
+			name:    "not_found",
+			key:     "config/missing.json",
+			wantErr: ErrObjectNotFound,
+		},
+		{
+			name:    "too_large",
+			key:     "config/app.json",
+			opts:    []DownloadOption{WithMaxSize(10)},
+			wantErr: ErrObjectTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config testConfig
+
+			err := client.GetObjectJSON(context.Background(), "bucket", tt.key, &config, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_UploadJSON(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	want := testConfig{Name: "loader", Retries: 3, Tags: []string{"a", "b"}}
+
+	tests := []struct {
+		name     string
+		filename string
+		opts     []UploadOption
+		wantBody string
+	}{
+		{
+			name:     "compact",
+			filename: "app.json",
+			wantBody: `{"name":"loader","retries":3,"tags":["a","b"]}`,
+		},
+		{
+			name:     "pretty",
+			filename: "app.conf",
+			opts:     []UploadOption{WithPrettyJSON()},
+			wantBody: "{\n  \"name\": \"loader\",\n  \"retries\": 3,\n  \"tags\": [\n    \"a\",\n    \"b\"\n  ]\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UploadJSON(context.Background(), "bucket", "config", tt.filename, want, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			object, ok := fake.get("bucket", "config/"+tt.filename)
+			if !ok {
+				t.Fatal("object was not uploaded")
+			}
+
+			if string(object.body) != tt.wantBody {
+				t.Errorf("actual `%s` \n expected `%s`", object.body, tt.wantBody)
+			}
+
+			if object.contentType != "application/json" {
+				t.Errorf("actual `%s` \n expected `%s`", object.contentType, "application/json")
+			}
+
+			var got testConfig
+
+			err = client.GetObjectJSON(context.Background(), "bucket", "config/"+tt.filename, &got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("actual `%+v` \n expected `%+v`", got, want)
+			}
+		})
+	}
+
+	_, err := client.UploadJSON(context.Background(), "bucket", "config", "bad.json", make(chan int))
+	if !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("actual error `%v` \n expected ErrInvalidJSON", err)
+	}
+}
@@ -0,0 +1,155 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// LifecycleRule is a bucket lifecycle rule applying to the objects under the prefix.
+// A zero number of days disables the corresponding action.
+type LifecycleRule struct {
+	// ID identifies the rule. S3 generates an ID if it is empty.
+	ID     string `json:"id,omitempty"`
+	Prefix string `json:"prefix"`
+	// ExpirationDays is the age in days after which objects are deleted.
+	ExpirationDays int `json:"expiration_days,omitempty"`
+	// TransitionDays is the age in days after which objects are moved to the storage class.
+	TransitionDays int                          `json:"transition_days,omitempty"`
+	StorageClass   types.TransitionStorageClass `json:"storage_class,omitempty"`
+}
+
+// PutBucketLifecycle replaces the lifecycle configuration of the bucket with the rules.
+func (s *Client) PutBucketLifecycle(ctx context.Context, bucketName string, rules []LifecycleRule) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if len(rules) == 0 {
+		return NewValidationError("lifecycle rules are empty")
+	}
+
+	sdkRules := make([]types.LifecycleRule, 0, len(rules))
+
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+
+		sdkRules = append(sdkRules, rule.toSDK())
+	}
+
+	_, err := s.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{
+			Rules: sdkRules,
+		},
+	})
+	if err != nil {
+		return NewS3Error("unable to put bucket lifecycle", err)
+	}
+
+	return nil
+}
+
+// GetBucketLifecycle returns the lifecycle rules of the bucket, or nil if it has no lifecycle configuration.
+// Rules using features not covered by LifecycleRule, e.g. tag filters, are returned with these features omitted.
+func (s *Client) GetBucketLifecycle(ctx context.Context, bucketName string) ([]LifecycleRule, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	lifecycleResp, err := s.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+
+		return nil, NewS3Error("unable to get bucket lifecycle", err)
+	}
+
+	rules := make([]LifecycleRule, 0, len(lifecycleResp.Rules))
+	for _, rule := range lifecycleResp.Rules {
+		rules = append(rules, lifecycleRuleFromSDK(rule))
+	}
+
+	return rules, nil
+}
+
+func (r LifecycleRule) validate() error {
+	if r.ExpirationDays < 0 || r.TransitionDays < 0 {
+		return NewValidationError("lifecycle days must not be negative")
+	}
+
+	if r.ExpirationDays == 0 && r.TransitionDays == 0 {
+		return NewValidationError("lifecycle rule has no expiration or transition")
+	}
+
+	if r.TransitionDays > 0 && !slices.Contains(r.StorageClass.Values(), r.StorageClass) {
+		return NewValidationError("unknown transition storage class: " + string(r.StorageClass))
+	}
+
+	if r.TransitionDays > 0 && r.ExpirationDays > 0 && r.ExpirationDays <= r.TransitionDays {
+		return NewValidationError("lifecycle expiration must come after the transition")
+	}
+
+	return nil
+}
+
+func (r LifecycleRule) toSDK() types.LifecycleRule {
+	rule := types.LifecycleRule{
+		Status: types.ExpirationStatusEnabled,
+		Filter: &types.LifecycleRuleFilter{
+			Prefix: aws.String(r.Prefix),
+		},
+	}
+
+	if r.ID != "" {
+		rule.ID = aws.String(r.ID)
+	}
+
+	if r.ExpirationDays > 0 {
+		rule.Expiration = &types.LifecycleExpiration{
+			Days: aws.Int32(int32(r.ExpirationDays)),
+		}
+	}
+
+	if r.TransitionDays > 0 {
+		rule.Transitions = []types.Transition{{
+			Days:         aws.Int32(int32(r.TransitionDays)),
+			StorageClass: r.StorageClass,
+		}}
+	}
+
+	return rule
+}
+
+func lifecycleRuleFromSDK(rule types.LifecycleRule) LifecycleRule {
+	result := LifecycleRule{
+		ID:     aws.ToString(rule.ID),
+		Prefix: aws.ToString(rule.Prefix),
+	}
+
+	if rule.Filter != nil && rule.Filter.Prefix != nil {
+		result.Prefix = aws.ToString(rule.Filter.Prefix)
+	}
+
+	if rule.Expiration != nil {
+		result.ExpirationDays = int(aws.ToInt32(rule.Expiration.Days))
+	}
+
+	if len(rule.Transitions) > 0 {
+		result.TransitionDays = int(aws.ToInt32(rule.Transitions[0].Days))
+		result.StorageClass = rule.Transitions[0].StorageClass
+	}
+
+	return result
+}
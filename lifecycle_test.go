@@ -0,0 +1,75 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestClient_BucketLifecycle(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	rules, err := client.GetBucketLifecycle(context.Background(), "bucket")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rules != nil {
+		t.Errorf("actual `%v` \n expected no rules", rules)
+	}
+
+	want := []LifecycleRule{
+		{ID: "expire-tmp", Prefix: "tmp/", ExpirationDays: 7},
+		{ID: "archive-raw", Prefix: "raw/", TransitionDays: 30, StorageClass: types.TransitionStorageClassGlacier, ExpirationDays: 365},
+	}
+
+	if err := client.PutBucketLifecycle(context.Background(), "bucket", want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rules, err = client.GetBucketLifecycle(context.Background(), "bucket")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("actual `%+v` \n expected `%+v`", rules, want)
+	}
+}
+
+func TestLifecycleRule_validate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule LifecycleRule
+	}{
+		{
+			name: "no_action",
+			rule: LifecycleRule{Prefix: "tmp/"},
+		},
+		{
+			name: "negative_days",
+			rule: LifecycleRule{Prefix: "tmp/", ExpirationDays: -1},
+		},
+		{
+			name: "unknown_storage_class",
+			rule: LifecycleRule{Prefix: "raw/", TransitionDays: 30, StorageClass: "COLD"},
+		},
+		{
+			name: "expiration_before_transition",
+			rule: LifecycleRule{Prefix: "raw/", TransitionDays: 30, StorageClass: types.TransitionStorageClassGlacier, ExpirationDays: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr ValidationError
+			if err := tt.rule.validate(); !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
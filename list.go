@@ -0,0 +1,186 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ListObjects returns all objects under the prefix.
+func (s *Client) ListObjects(ctx context.Context, bucketName string, prefix string, opts ...ListOption) ([]ObjectInfo, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	o := newListOptions(opts)
+
+	var objects []ObjectInfo
+
+	err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		objects = append(objects, o.objectInfo(object))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// errStopListing stops walkObjects when the caller of ListObjectsStream stops the iteration.
+var errStopListing = errors.New("listing stopped")
+
+// ListObjectsStream returns an iterator over the objects under the prefix, like ListObjects, that fetches
+// the pages of the listing lazily, so listings of any size are processed without keeping them in memory.
+// The listing stops when the loop is left or ctx is cancelled. Errors, including validation errors,
+// are yielded once with an empty ObjectInfo and end the iteration.
+func (s *Client) ListObjectsStream(ctx context.Context, bucketName string, prefix string, opts ...ListOption) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		if bucketName == "" {
+			yield(ObjectInfo{}, NewValidationError("bucket name is empty"))
+
+			return
+		}
+
+		o := newListOptions(opts)
+
+		err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+			if err := ctx.Err(); err != nil {
+				return NewS3Error("unable to list objects", err)
+			}
+
+			if !yield(o.objectInfo(object), nil) {
+				return errStopListing
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopListing) {
+			yield(ObjectInfo{}, err)
+		}
+	}
+}
+
+// CountObjects counts the objects under the prefix for which the predicate returns true.
+// The listing is processed page by page without keeping it in memory. A nil predicate counts all objects.
+func (s *Client) CountObjects(ctx context.Context, bucketName string, prefix string, predicate func(ObjectInfo) bool) (int64, error) {
+	if bucketName == "" {
+		return 0, NewValidationError("bucket name is empty")
+	}
+
+	var count int64
+
+	err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		if predicate == nil || predicate(objectInfoFromObject(object)) {
+			count++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// IsPrefixEmpty reports whether no object has a key starting with the prefix, e.g. whether a folder is empty.
+// Unlike IsObjectExists it doesn't require an object with exactly the prefix as its key.
+func (s *Client) IsPrefixEmpty(ctx context.Context, bucketName string, prefix string) (bool, error) {
+	if bucketName == "" {
+		return false, NewValidationError("bucket name is empty")
+	}
+
+	listResp, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return false, NewS3Error("unable to list objects", err)
+	}
+
+	return len(listResp.Contents) == 0, nil
+}
+
+// ListCommonPrefixes returns the immediate child prefixes of the prefix, like the subdirectories of a directory,
+// e.g. "raw/2024/" and "raw/2025/" for the prefix "raw". The prefix is treated as a folder, so a trailing
+// slash is optional, and an empty prefix lists the top-level prefixes of the bucket.
+func (s *Client) ListCommonPrefixes(ctx context.Context, bucketName string, prefix string) ([]string, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(bucketName),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+		MaxKeys:   s.options.maxKeys,
+	})
+
+	var prefixes []string
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, NewS3Error("unable to list objects", err)
+		}
+
+		for _, commonPrefix := range page.CommonPrefixes {
+			prefixes = append(prefixes, aws.ToString(commonPrefix.Prefix))
+		}
+	}
+
+	return prefixes, nil
+}
+
+// objectInfo converts the listed object, handling a key that is not valid UTF-8 as configured.
+func (o listOptions) objectInfo(object types.Object) ObjectInfo {
+	info := objectInfoFromObject(object)
+
+	if o.invalidKeyHandling != InvalidKeyKeep && !utf8.ValidString(info.Key) {
+		info.InvalidUTF8 = true
+
+		if o.invalidKeyHandling == InvalidKeyPercentEncode {
+			info.Key = percentEncodeInvalidUTF8(info.Key)
+		}
+	}
+
+	return info
+}
+
+// walkObjects calls fn for every object under the prefix, paginating through the listing.
+func (s *Client) walkObjects(ctx context.Context, bucketName string, prefix string, fn func(object types.Object) error) error {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucketName),
+		Prefix:  aws.String(prefix),
+		MaxKeys: s.options.maxKeys,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return NewS3Error("unable to list objects", err)
+		}
+
+		for _, object := range page.Contents {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
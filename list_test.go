@@ -0,0 +1,371 @@
+package s3utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClient_ListObjects_invalidUTF8(t *testing.T) {
+	const invalidKey = "raw/\xffdata%1.json"
+
+	fake := newFakeS3()
+	fake.put("bucket", invalidKey, []byte("a"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name        string
+		opts        []ListOption
+		wantKey     string
+		wantInvalid bool
+	}{
+		{
+			name:    "keep",
+			wantKey: invalidKey,
+		},
+		{
+			name:        "flag",
+			opts:        []ListOption{WithInvalidUTF8Keys(InvalidKeyFlag)},
+			wantKey:     invalidKey,
+			wantInvalid: true,
+		},
+		{
+			name:        "percent_encode",
+			opts:        []ListOption{WithInvalidUTF8Keys(InvalidKeyPercentEncode)},
+			wantKey:     "raw/%FFdata%251.json",
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := client.ListObjects(context.Background(), "bucket", "raw/", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(objects) != 1 {
+				t.Fatalf("actual `%v` objects \n expected `1`", len(objects))
+			}
+
+			if objects[0].Key != tt.wantKey || objects[0].InvalidUTF8 != tt.wantInvalid {
+				t.Errorf("actual `%q` (%v) \n expected `%q` (%v)", objects[0].Key, objects[0].InvalidUTF8, tt.wantKey, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func Test_percentEncodeInvalidUTF8_roundTrip(t *testing.T) {
+	const key = "raw/\xff\xfeпривет%20.json"
+
+	encoded := percentEncodeInvalidUTF8(key)
+
+	body, err := json.Marshal(ObjectInfo{Key: encoded})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var info ObjectInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded, err := url.PathUnescape(info.Key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decoded != key {
+		t.Errorf("actual `%q` \n expected `%q`", decoded, key)
+	}
+}
+
+func TestClient_CountObjects(t *testing.T) {
+	fake := newFakeS3()
+	fake.pageSize = 2
+	fake.put("bucket", "raw/a.json", []byte("1"))
+	fake.put("bucket", "raw/b.json", []byte("12345"))
+	fake.put("bucket", "raw/c.json", []byte("123456"))
+	fake.put("bucket", "raw/d.json", []byte("12"))
+	fake.put("bucket", "other/e.json", []byte("1234567"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name      string
+		predicate func(ObjectInfo) bool
+		want      int64
+	}{
+		{
+			name:      "over_threshold",
+			predicate: func(info ObjectInfo) bool { return info.Size > 4 },
+			want:      2,
+		},
+		{
+			name: "nil_predicate",
+			want: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.CountObjects(context.Background(), "bucket", "raw/", tt.predicate)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ListObjectsStream(t *testing.T) {
+	fake := newFakeS3()
+	fake.pageSize = 2
+
+	want := []string{"raw/a.json", "raw/b.json", "raw/c.json", "raw/d.json", "raw/e.json"}
+	for _, key := range want {
+		fake.put("bucket", key, []byte("1"))
+	}
+
+	client := newTestClient(t, fake)
+
+	var got []string
+
+	for info, err := range client.ListObjectsStream(context.Background(), "bucket", "raw/") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, info.Key)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("actual `%v` \n expected `%v`", got, want)
+	}
+
+	// Leaving the loop on the second page doesn't fetch the third one.
+	fake.listInputs = nil
+
+	for info, err := range client.ListObjectsStream(context.Background(), "bucket", "raw/") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if info.Key == "raw/c.json" {
+			break
+		}
+	}
+
+	if len(fake.listInputs) != 2 {
+		t.Errorf("actual %d requests \n expected 2", len(fake.listInputs))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var errs []error
+
+	for _, err := range client.ListObjectsStream(ctx, "bucket", "raw/") {
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		cancel()
+	}
+
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("actual errors `%v` \n expected a single `%v`", errs, context.Canceled)
+	}
+
+	for _, err := range client.ListObjectsStream(context.Background(), "", "raw/") {
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestClient_IsPrefixEmpty(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/2024/data.json", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   bool
+	}{
+		{
+			name:   "folder",
+			prefix: "raw/",
+			want:   false,
+		},
+		{
+			name:   "nested_folder",
+			prefix: "raw/2024",
+			want:   false,
+		},
+		{
+			name:   "missing_folder",
+			prefix: "processed/",
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.IsPrefixEmpty(context.Background(), "bucket", tt.prefix)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_IsObjectExists(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/2024/data.json", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name string
+		key  string
+		want bool
+	}{
+		{
+			name: "object",
+			key:  "raw/2024/data.json",
+			want: true,
+		},
+		{
+			name: "folder",
+			key:  "raw/2024",
+			want: false,
+		},
+		{
+			name: "key_prefix",
+			key:  "raw/2024/data",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.IsObjectExists(context.Background(), "bucket", tt.key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithMaxKeys(t *testing.T) {
+	fake := newFakeS3()
+	for _, key := range []string{"raw/1", "raw/2", "raw/3", "raw/4", "raw/5"} {
+		fake.put("bucket", key, []byte("a"))
+	}
+
+	client := newTestClient(t, fake, WithMaxKeys(2))
+
+	deleted, err := client.DeleteFolder(context.Background(), "bucket", "raw")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(deleted) != 5 {
+		t.Errorf("actual %d deleted keys \n expected 5", len(deleted))
+	}
+
+	if len(fake.listInputs) != 3 {
+		t.Errorf("actual %d list requests \n expected 3", len(fake.listInputs))
+	}
+
+	for _, input := range fake.listInputs {
+		if aws.ToInt32(input.MaxKeys) != 2 {
+			t.Errorf("actual max keys `%v` \n expected `2`", aws.ToInt32(input.MaxKeys))
+		}
+	}
+
+	if empty, _ := client.IsPrefixEmpty(context.Background(), "bucket", "raw/"); !empty {
+		t.Errorf("folder `raw` was not deleted")
+	}
+
+	for _, n := range []int32{0, -1, 1001} {
+		_, err := NewClientWithAPI(fake, "us-east-1", WithMaxKeys(n))
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` for %d \n expected ValidationError", err, n)
+		}
+	}
+}
+
+func TestClient_ListCommonPrefixes(t *testing.T) {
+	fake := newFakeS3()
+	fake.pageSize = 2
+
+	for _, key := range []string{"raw/2024/01/a.json", "raw/2024/02/b.json", "raw/2025/c.json", "raw/d.json", "raw/e/", "rawdata/f.json", "top.json"} {
+		fake.put("bucket", key, []byte("a"))
+	}
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{
+			name:   "folder",
+			prefix: "raw",
+			want:   []string{"raw/2024/", "raw/2025/", "raw/e/"},
+		},
+		{
+			name:   "trailing_slash",
+			prefix: "/raw/2024/",
+			want:   []string{"raw/2024/01/", "raw/2024/02/"},
+		},
+		{
+			name:   "bucket_root",
+			prefix: "",
+			want:   []string{"raw/", "rawdata/"},
+		},
+		{
+			name:   "no_subfolders",
+			prefix: "raw/2025",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ListCommonPrefixes(context.Background(), "bucket", tt.prefix)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// GenerateChecksumManifest stores a JSON manifest at manifestKey mapping every object key under the prefix
+// to its hex-encoded SHA256.
+// The checksum stored by S3 is used when available, otherwise the object is downloaded and hashed.
+// The upload options apply to the manifest, except WithContentLength, PreserveSlashes and the options
+// of UploadFileWithDateDestination, which are rejected with a ValidationError.
+func (s *Client) GenerateChecksumManifest(ctx context.Context, bucketName string, prefix string, manifestKey string, opts ...UploadOption) error {
+	if err := validateBucketName(bucketName); err != nil {
+		return err
+	}
+
+	if manifestKey == "" {
+		return NewValidationError("manifest key is empty")
+	}
+
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := o.validateKeyUpload("GenerateChecksumManifest"); err != nil {
+		return err
+	}
+
+	manifest := make(map[string]string)
+
+	err = s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		key := aws.ToString(object.Key)
+		if key == manifestKey {
+			return nil
+		}
+
+		checksum, err := s.objectSHA256(ctx, bucketName, key)
+		if err != nil {
+			return err
+		}
+
+		manifest[key] = checksum
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return NewSDKError("unable to marshal manifest", err)
+	}
+
+	o.contentType = jsonContentType
+	o.contentLength = aws.Int64(int64(len(body)))
+
+	_, err = s.putStream(ctx, bucketName, manifestKey, bytes.NewReader(body), o)
+
+	return err
+}
+
+// objectSHA256 returns the hex-encoded SHA256 of an object.
+// Composite checksums of multipart objects don't cover the whole content, so such objects are hashed locally.
+func (s *Client) objectSHA256(ctx context.Context, bucketName string, key string) (string, error) {
+	attributesResp, err := s.client.GetObjectAttributes(ctx, &s3.GetObjectAttributesInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		ObjectAttributes: []types.ObjectAttributes{
+			types.ObjectAttributesChecksum,
+			types.ObjectAttributesObjectParts,
+		},
+	})
+	if err != nil {
+		return "", newGetObjectError("unable to get object attributes", err)
+	}
+
+	isMultipart := attributesResp.ObjectParts != nil && aws.ToInt32(attributesResp.ObjectParts.TotalPartsCount) > 0
+	if attributesResp.Checksum != nil && attributesResp.Checksum.ChecksumSHA256 != nil && !isMultipart {
+		checksum, err := base64.StdEncoding.DecodeString(*attributesResp.Checksum.ChecksumSHA256)
+		if err != nil {
+			return "", NewSDKError("unable to decode object checksum", err)
+		}
+
+		return hex.EncodeToString(checksum), nil
+	}
+
+	getResp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", newGetObjectError("unable to get object", err)
+	}
+
+	defer getResp.Body.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, getResp.Body)
+	if err != nil {
+		return "", NewSDKError("unable to read S3 response body", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
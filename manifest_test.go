@@ -0,0 +1,86 @@
+package s3utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClient_GenerateChecksumManifest(t *testing.T) {
+	fake := newFakeS3()
+	fake.pageSize = 1
+	fake.put("bucket", "raw/a.json", []byte(`{"a":1}`))
+	fake.put("bucket", "raw/b.json", []byte(`{"b":2}`))
+	fake.put("bucket", "other/c.json", []byte(`{"c":3}`))
+
+	// The stored checksum must be preferred over hashing the content.
+	stored := sha256.Sum256([]byte("stored"))
+	object := fake.objects["bucket"]["raw/b.json"]
+	object.checksumSHA256 = base64.StdEncoding.EncodeToString(stored[:])
+	fake.objects["bucket"]["raw/b.json"] = object
+
+	client := newTestClient(t, fake)
+
+	err := client.GenerateChecksumManifest(context.Background(), "bucket", "raw/", "raw/manifest.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	manifestObject, ok := fake.get("bucket", "raw/manifest.json")
+	if !ok {
+		t.Fatal("manifest was not uploaded")
+	}
+
+	var manifest map[string]string
+	if err := json.Unmarshal(manifestObject.body, &manifest); err != nil {
+		t.Fatalf("unable to unmarshal manifest: %v", err)
+	}
+
+	computed := sha256.Sum256([]byte(`{"a":1}`))
+	want := map[string]string{
+		"raw/a.json": hex.EncodeToString(computed[:]),
+		"raw/b.json": hex.EncodeToString(stored[:]),
+	}
+
+	if len(manifest) != len(want) {
+		t.Errorf("actual `%v` \n expected `%v`", manifest, want)
+	}
+
+	for key, checksum := range want {
+		if manifest[key] != checksum {
+			t.Errorf("key `%v`: actual `%v` \n expected `%v`", key, manifest[key], checksum)
+		}
+	}
+}
+
+func TestClient_GenerateChecksumManifest_uploadOptions(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/a.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake)
+
+	err := client.GenerateChecksumManifest(context.Background(), "bucket", "raw/", "raw/manifest.json", WithEncryptionContext("key-id", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input := fake.putInputs[len(fake.putInputs)-1]
+	if got := aws.ToString(input.ContentType); got != jsonContentType {
+		t.Errorf("actual content type `%v` \n expected `%v`", got, jsonContentType)
+	}
+
+	if got := aws.ToString(input.SSEKMSKeyId); got != "key-id" {
+		t.Errorf("actual KMS key ID `%v` \n expected `key-id`", got)
+	}
+
+	var validationErr ValidationError
+	if err := client.GenerateChecksumManifest(context.Background(), "My_Bucket", "raw/", "raw/manifest.json"); !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
@@ -0,0 +1,1076 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+type fakeObject struct {
+	body            []byte
+	contentType     string
+	contentEncoding string
+	etag            string
+	lastModified    time.Time
+	checksumSHA256  string
+	metadata        map[string]string
+	restore         string
+	retainUntil     time.Time
+	cacheControl    string
+	tags            map[string]string
+}
+
+// fakeS3 is an in-memory implementation of S3API. Methods that are not implemented panic.
+type fakeS3 struct {
+	S3API
+
+	mu      sync.Mutex
+	objects map[string]map[string]fakeObject
+	// pageSize limits the number of keys returned by a single ListObjectsV2 call.
+	pageSize int
+	// lockedKeys are reported as failed by DeleteObjects.
+	lockedKeys map[string]bool
+	// putInputs records the inputs of all PutObject calls.
+	putInputs []*s3.PutObjectInput
+	// putETag overrides the ETag returned by PutObject.
+	putETag string
+	// delay is the latency of every call.
+	delay time.Duration
+	// versions holds the version ids of objects by bucket and key, the latest version last.
+	versions map[string]map[string][]string
+	// deleteMarkers holds the version ids of delete markers by bucket and key.
+	deleteMarkers map[string]map[string][]string
+	// versionPageSize limits the number of versions returned by a single ListObjectVersions call.
+	versionPageSize int
+	// copyInputs records the inputs of all CopyObject calls.
+	copyInputs []*s3.CopyObjectInput
+	// objectLockBuckets are the buckets with object lock enabled.
+	objectLockBuckets map[string]bool
+	// lifecycleRules holds the lifecycle configuration of buckets.
+	lifecycleRules map[string][]types.LifecycleRule
+	// createBucketInputs records the inputs of all CreateBucket calls.
+	createBucketInputs []*s3.CreateBucketInput
+	// foreignBuckets are the buckets owned by another account.
+	foreignBuckets map[string]bool
+	// bucketLogging holds the server access logging status of buckets.
+	bucketLogging map[string]*types.BucketLoggingStatus
+	// headMisses is the number of HeadObject calls that report existing objects as not found,
+	// simulating eventual consistency.
+	headMisses int
+	// headSizeDelta is added to the ContentLength returned by HeadObject.
+	headSizeDelta int64
+	// bucketLocations holds the location constraints of buckets, empty for us-east-1.
+	bucketLocations map[string]types.BucketLocationConstraint
+	// multipartUploads holds the incomplete multipart uploads of buckets.
+	multipartUploads map[string][]types.MultipartUpload
+	// uploadedParts holds the parts uploaded to multipart uploads by upload ID and part number.
+	uploadedParts map[string]map[int32][]byte
+	// deleteInputs records the inputs of all DeleteObjects calls.
+	deleteInputs []*s3.DeleteObjectsInput
+	// deletesInFlight and maxDeletesInFlight count the concurrent DeleteObjects calls.
+	deletesInFlight    int
+	maxDeletesInFlight int
+	// listInputs records the inputs of all ListObjectsV2 calls.
+	listInputs []*s3.ListObjectsV2Input
+	// wrapBody wraps the body returned by GetObject, e.g. to interrupt the download.
+	wrapBody func(io.Reader) io.Reader
+	// multipartInputs holds the inputs of the CreateMultipartUpload calls by upload ID.
+	multipartInputs map[string]*s3.CreateMultipartUploadInput
+}
+
+func newTestClient(t *testing.T, api S3API, opts ...Option) *Client {
+	t.Helper()
+
+	client, err := NewClientWithAPI(api, "us-east-1", opts...)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+
+	return client
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:         make(map[string]map[string]fakeObject),
+		pageSize:        1000,
+		versions:        make(map[string]map[string][]string),
+		versionPageSize: 1000,
+	}
+}
+
+func (f *fakeS3) wait(ctx context.Context) error {
+	if f.delay == 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(f.delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (f *fakeS3) put(bucketName string, key string, body []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.putLocked(bucketName, key, body)
+}
+
+func (f *fakeS3) putLocked(bucketName string, key string, body []byte) {
+	if f.objects[bucketName] == nil {
+		f.objects[bucketName] = make(map[string]fakeObject)
+	}
+
+	sum := md5.Sum(body)
+	f.objects[bucketName][key] = fakeObject{
+		body:         body,
+		etag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		lastModified: time.Now().UTC(),
+	}
+}
+
+func (f *fakeS3) get(bucketName string, key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.objects[bucketName][key]
+
+	return object, ok
+}
+
+var errPreconditionFailed = &smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if params.ObjectLockMode != "" && !f.objectLockBuckets[aws.ToString(params.Bucket)] {
+		return nil, errMissingObjectLock
+	}
+
+	existing, exists := f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)]
+	if params.IfNoneMatch != nil && exists || params.IfMatch != nil && (!exists || aws.ToString(params.IfMatch) != existing.etag) {
+		return nil, errPreconditionFailed
+	}
+
+	f.putInputs = append(f.putInputs, params)
+	f.putLocked(aws.ToString(params.Bucket), aws.ToString(params.Key), body)
+
+	object := f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)]
+	object.contentType = aws.ToString(params.ContentType)
+	object.contentEncoding = aws.ToString(params.ContentEncoding)
+	object.metadata = params.Metadata
+	object.retainUntil = aws.ToTime(params.ObjectLockRetainUntilDate)
+	f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)] = object
+
+	if f.putETag != "" {
+		return &s3.PutObjectOutput{ETag: aws.String(f.putETag)}, nil
+	}
+
+	output := &s3.PutObjectOutput{
+		ETag: aws.String(object.etag),
+	}
+
+	switch params.ChecksumAlgorithm {
+	case types.ChecksumAlgorithmCrc32c:
+		sum := crc32.Checksum(body, crc32.MakeTable(crc32.Castagnoli))
+		output.ChecksumCRC32C = aws.String(base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint32(nil, sum)))
+	case types.ChecksumAlgorithmSha256:
+		sum := sha256.Sum256(body)
+		output.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	object, ok := f.get(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	if params.IfNoneMatch != nil && aws.ToString(params.IfNoneMatch) == object.etag {
+		return nil, &smithy.GenericAPIError{Code: "NotModified", Message: "Not Modified"}
+	}
+
+	if params.IfModifiedSince != nil && !object.lastModified.After(aws.ToTime(params.IfModifiedSince)) {
+		return nil, &smithy.GenericAPIError{Code: "NotModified", Message: "Not Modified"}
+	}
+
+	body := object.body
+
+	var contentRange *string
+
+	if params.Range != nil {
+		var start, end int64
+
+		_, err := fmt.Sscanf(aws.ToString(params.Range), "bytes=%d-%d", &start, &end)
+		if err != nil {
+			return nil, err
+		}
+
+		if start >= int64(len(body)) {
+			return nil, &smithy.GenericAPIError{Code: "InvalidRange", Message: "The requested range is not satisfiable"}
+		}
+
+		end = min(end, int64(len(body))-1)
+		contentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(body)))
+		body = body[start : end+1]
+	}
+
+	var reader io.Reader = bytes.NewReader(body)
+	if f.wrapBody != nil {
+		reader = f.wrapBody(reader)
+	}
+
+	return &s3.GetObjectOutput{
+		Body:            io.NopCloser(reader),
+		ContentLength:   aws.Int64(int64(len(body))),
+		ContentRange:    contentRange,
+		ContentType:     aws.String(object.contentType),
+		ContentEncoding: aws.String(object.contentEncoding),
+		ETag:            aws.String(object.etag),
+		LastModified:    aws.Time(object.lastModified),
+		Metadata:        object.metadata,
+	}, nil
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, _ ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	object, ok := f.get(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+
+	f.mu.Lock()
+	miss := f.headMisses > 0
+	if miss {
+		f.headMisses--
+	}
+	f.mu.Unlock()
+
+	if miss {
+		return nil, &types.NotFound{}
+	}
+
+	output := &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(object.body)) + f.headSizeDelta),
+		ContentType:   aws.String(object.contentType),
+		CacheControl:  aws.String(object.cacheControl),
+		ETag:          aws.String(object.etag),
+		LastModified:  aws.Time(object.lastModified),
+		Metadata:      object.metadata,
+		Restore:       aws.String(object.restore),
+	}
+
+	if params.ChecksumMode == types.ChecksumModeEnabled && object.checksumSHA256 != "" {
+		output.ChecksumSHA256 = aws.String(object.checksumSHA256)
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, _ ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	object, ok := f.get(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	output := &s3.GetObjectTaggingOutput{}
+	for key, value := range object.tags {
+		output.TagSet = append(output.TagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) CopyObject(ctx context.Context, params *s3.CopyObjectInput, _ ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	srcBucket, srcKey, _ := strings.Cut(aws.ToString(params.CopySource), "/")
+
+	srcKey, err := url.PathUnescape(srcKey)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.copyInputs = append(f.copyInputs, params)
+
+	object, ok := f.objects[srcBucket][srcKey]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	f.putLocked(aws.ToString(params.Bucket), aws.ToString(params.Key), object.body)
+
+	copied := f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)]
+	copied.contentType, copied.metadata = object.contentType, object.metadata
+
+	if params.MetadataDirective == types.MetadataDirectiveReplace {
+		copied.contentType, copied.metadata = aws.ToString(params.ContentType), params.Metadata
+	}
+
+	result := &types.CopyObjectResult{ETag: aws.String(copied.etag)}
+
+	if params.ChecksumAlgorithm == types.ChecksumAlgorithmSha256 {
+		sum := sha256.Sum256(copied.body)
+		copied.checksumSHA256 = base64.StdEncoding.EncodeToString(sum[:])
+		result.ChecksumSHA256 = aws.String(copied.checksumSHA256)
+	}
+
+	f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)] = copied
+
+	return &s3.CopyObjectOutput{
+		CopyObjectResult: result,
+	}, nil
+}
+
+func (f *fakeS3) GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, _ ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	object, ok := f.get(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	output := &s3.GetObjectAttributesOutput{
+		ETag:         aws.String(object.etag),
+		LastModified: aws.Time(object.lastModified),
+		ObjectSize:   aws.Int64(int64(len(object.body))),
+	}
+
+	if object.checksumSHA256 != "" {
+		output.Checksum = &types.Checksum{
+			ChecksumSHA256: aws.String(object.checksumSHA256),
+		}
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.listInputs = append(f.listInputs, params)
+
+	prefix, delimiter := aws.ToString(params.Prefix), aws.ToString(params.Delimiter)
+
+	keys := make([]string, 0, len(f.objects[aws.ToString(params.Bucket)]))
+	for key := range f.objects[aws.ToString(params.Bucket)] {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		// Keys containing the delimiter after the prefix are rolled up into their common prefix.
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			key = key[:len(prefix)+i+len(delimiter)]
+		}
+
+		if key > aws.ToString(params.ContinuationToken) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	output := &s3.ListObjectsV2Output{}
+
+	pageSize := f.pageSize
+	if params.MaxKeys != nil {
+		pageSize = min(pageSize, int(aws.ToInt32(params.MaxKeys)))
+	}
+
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		output.IsTruncated = aws.Bool(true)
+		output.NextContinuationToken = aws.String(keys[len(keys)-1])
+	}
+
+	for _, key := range keys {
+		object, ok := f.objects[aws.ToString(params.Bucket)][key]
+		if !ok || delimiter != "" && strings.HasSuffix(key, delimiter) && len(key) > len(prefix) {
+			output.CommonPrefixes = append(output.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(key)})
+
+			continue
+		}
+
+		output.Contents = append(output.Contents, types.Object{
+			Key:          aws.String(key),
+			ETag:         aws.String(object.etag),
+			Size:         aws.Int64(int64(len(object.body))),
+			LastModified: aws.Time(object.lastModified),
+		})
+	}
+
+	output.KeyCount = aws.Int32(int32(len(output.Contents)))
+
+	return output, nil
+}
+
+func (f *fakeS3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, _ ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	f.mu.Lock()
+	f.deletesInFlight++
+	f.maxDeletesInFlight = max(f.maxDeletesInFlight, f.deletesInFlight)
+	f.mu.Unlock()
+
+	err := f.wait(ctx)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deletesInFlight--
+
+	if err != nil {
+		return nil, err
+	}
+
+	f.deleteInputs = append(f.deleteInputs, params)
+
+	output := &s3.DeleteObjectsOutput{}
+
+	for _, object := range params.Delete.Objects {
+		if f.lockedKeys[aws.ToString(object.Key)] {
+			output.Errors = append(output.Errors, types.Error{
+				Key:     object.Key,
+				Code:    aws.String("AccessDenied"),
+				Message: aws.String("Access Denied because object protected by object lock."),
+			})
+
+			continue
+		}
+
+		bucketName, key := aws.ToString(params.Bucket), aws.ToString(object.Key)
+
+		if object.VersionId != nil {
+			isVersion := func(versionID string) bool {
+				return versionID == aws.ToString(object.VersionId)
+			}
+			if f.versions[bucketName] != nil {
+				f.versions[bucketName][key] = slices.DeleteFunc(f.versions[bucketName][key], isVersion)
+			}
+
+			if f.deleteMarkers[bucketName] != nil {
+				f.deleteMarkers[bucketName][key] = slices.DeleteFunc(f.deleteMarkers[bucketName][key], isVersion)
+			}
+		} else {
+			delete(f.objects[bucketName], key)
+		}
+
+		if !aws.ToBool(params.Delete.Quiet) {
+			output.Deleted = append(output.Deleted, types.DeletedObject{Key: object.Key})
+		}
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, _ ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucketName, key := aws.ToString(params.Bucket), aws.ToString(params.Key)
+
+	if params.VersionId != nil {
+		f.versions[bucketName][key] = slices.DeleteFunc(f.versions[bucketName][key], func(versionID string) bool {
+			return versionID == aws.ToString(params.VersionId)
+		})
+
+		return &s3.DeleteObjectOutput{VersionId: params.VersionId}, nil
+	}
+
+	delete(f.objects[bucketName], key)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (f *fakeS3) RestoreObject(ctx context.Context, params *s3.RestoreObjectInput, _ ...func(*s3.Options)) (*s3.RestoreObjectOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	object.restore = `ongoing-request="true"`
+	f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)] = object
+
+	return &s3.RestoreObjectOutput{}, nil
+}
+
+func (f *fakeS3) PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, _ ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lifecycleRules == nil {
+		f.lifecycleRules = make(map[string][]types.LifecycleRule)
+	}
+
+	f.lifecycleRules[aws.ToString(params.Bucket)] = params.LifecycleConfiguration.Rules
+
+	return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+func (f *fakeS3) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, _ ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rules, ok := f.lifecycleRules[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchLifecycleConfiguration", Message: "The lifecycle configuration does not exist"}
+	}
+
+	return &s3.GetBucketLifecycleConfigurationOutput{Rules: rules}, nil
+}
+
+func (f *fakeS3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, _ ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.objects[aws.ToString(params.Bucket)] == nil {
+		return nil, &types.NotFound{}
+	}
+
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func (f *fakeS3) CreateBucket(ctx context.Context, params *s3.CreateBucketInput, _ ...func(*s3.Options)) (*s3.CreateBucketOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.createBucketInputs = append(f.createBucketInputs, params)
+
+	if f.foreignBuckets[aws.ToString(params.Bucket)] {
+		return nil, &types.BucketAlreadyExists{}
+	}
+
+	if f.objects[aws.ToString(params.Bucket)] != nil {
+		return nil, &types.BucketAlreadyOwnedByYou{}
+	}
+
+	f.objects[aws.ToString(params.Bucket)] = make(map[string]fakeObject)
+
+	if params.CreateBucketConfiguration != nil {
+		if f.bucketLocations == nil {
+			f.bucketLocations = make(map[string]types.BucketLocationConstraint)
+		}
+
+		f.bucketLocations[aws.ToString(params.Bucket)] = params.CreateBucketConfiguration.LocationConstraint
+	}
+
+	return &s3.CreateBucketOutput{}, nil
+}
+
+func (f *fakeS3) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, _ ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.objects[aws.ToString(params.Bucket)] == nil {
+		return nil, &types.NoSuchBucket{}
+	}
+
+	return &s3.GetBucketLocationOutput{
+		LocationConstraint: f.bucketLocations[aws.ToString(params.Bucket)],
+	}, nil
+}
+
+func (f *fakeS3) PutBucketLogging(ctx context.Context, params *s3.PutBucketLoggingInput, _ ...func(*s3.Options)) (*s3.PutBucketLoggingOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.bucketLogging == nil {
+		f.bucketLogging = make(map[string]*types.BucketLoggingStatus)
+	}
+
+	f.bucketLogging[aws.ToString(params.Bucket)] = params.BucketLoggingStatus
+
+	return &s3.PutBucketLoggingOutput{}, nil
+}
+
+var errMissingObjectLock = &smithy.GenericAPIError{Code: "InvalidRequest", Message: "Bucket is missing Object Lock Configuration"}
+
+func (f *fakeS3) PutObjectRetention(ctx context.Context, params *s3.PutObjectRetentionInput, _ ...func(*s3.Options)) (*s3.PutObjectRetentionOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.objectLockBuckets[aws.ToString(params.Bucket)] {
+		return nil, errMissingObjectLock
+	}
+
+	object, ok := f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	object.retainUntil = aws.ToTime(params.Retention.RetainUntilDate)
+	f.objects[aws.ToString(params.Bucket)][aws.ToString(params.Key)] = object
+
+	return &s3.PutObjectRetentionOutput{}, nil
+}
+
+func (f *fakeS3) addVersions(bucketName string, key string, versionIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.versions[bucketName] == nil {
+		f.versions[bucketName] = make(map[string][]string)
+	}
+
+	f.versions[bucketName][key] = append(f.versions[bucketName][key], versionIDs...)
+}
+
+func (f *fakeS3) addDeleteMarkers(bucketName string, key string, versionIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.deleteMarkers == nil {
+		f.deleteMarkers = make(map[string]map[string][]string)
+	}
+
+	if f.deleteMarkers[bucketName] == nil {
+		f.deleteMarkers[bucketName] = make(map[string][]string)
+	}
+
+	f.deleteMarkers[bucketName][key] = append(f.deleteMarkers[bucketName][key], versionIDs...)
+}
+
+func (f *fakeS3) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, _ ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.versions[aws.ToString(params.Bucket)]))
+	for key := range f.versions[aws.ToString(params.Bucket)] {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	var versions []types.ObjectVersion
+
+	for _, key := range keys {
+		versionIDs := f.versions[aws.ToString(params.Bucket)][key]
+		for i, versionID := range versionIDs {
+			versions = append(versions, types.ObjectVersion{
+				Key:       aws.String(key),
+				VersionId: aws.String(versionID),
+				IsLatest:  aws.Bool(i == len(versionIDs)-1),
+			})
+		}
+	}
+
+	// Markers are encoded as "key/versionId" of the last returned version.
+	if params.KeyMarker != nil {
+		marker := aws.ToString(params.KeyMarker) + "/" + aws.ToString(params.VersionIdMarker)
+		for i, version := range versions {
+			if aws.ToString(version.Key)+"/"+aws.ToString(version.VersionId) == marker {
+				versions = versions[i+1:]
+
+				break
+			}
+		}
+	}
+
+	output := &s3.ListObjectVersionsOutput{}
+
+	if len(versions) > f.versionPageSize {
+		versions = versions[:f.versionPageSize]
+		last := versions[len(versions)-1]
+		output.IsTruncated = aws.Bool(true)
+		output.NextKeyMarker = last.Key
+		output.NextVersionIdMarker = last.VersionId
+	}
+
+	output.Versions = versions
+
+	// Delete markers are returned with the last page.
+	if !aws.ToBool(output.IsTruncated) {
+		for key, versionIDs := range f.deleteMarkers[aws.ToString(params.Bucket)] {
+			if !strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+				continue
+			}
+
+			for _, versionID := range versionIDs {
+				output.DeleteMarkers = append(output.DeleteMarkers, types.DeleteMarkerEntry{
+					Key:       aws.String(key),
+					VersionId: aws.String(versionID),
+				})
+			}
+		}
+	}
+
+	return output, nil
+}
+
+func (f *fakeS3) setLastModified(bucketName string, key string, lastModified time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object := f.objects[bucketName][key]
+	object.lastModified = lastModified
+	f.objects[bucketName][key] = object
+}
+
+func (f *fakeS3) ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, _ ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uploads := slices.Clone(f.multipartUploads[aws.ToString(params.Bucket)])
+	slices.SortFunc(uploads, func(a, b types.MultipartUpload) int {
+		return strings.Compare(aws.ToString(a.Key)+"/"+aws.ToString(a.UploadId), aws.ToString(b.Key)+"/"+aws.ToString(b.UploadId))
+	})
+
+	marker := aws.ToString(params.KeyMarker) + "/" + aws.ToString(params.UploadIdMarker)
+	uploads = slices.DeleteFunc(uploads, func(upload types.MultipartUpload) bool {
+		return !strings.HasPrefix(aws.ToString(upload.Key), aws.ToString(params.Prefix)) ||
+			params.KeyMarker != nil && aws.ToString(upload.Key)+"/"+aws.ToString(upload.UploadId) <= marker
+	})
+
+	output := &s3.ListMultipartUploadsOutput{}
+
+	if len(uploads) > f.pageSize {
+		uploads = uploads[:f.pageSize]
+		output.IsTruncated = aws.Bool(true)
+		output.NextKeyMarker = uploads[len(uploads)-1].Key
+		output.NextUploadIdMarker = uploads[len(uploads)-1].UploadId
+	}
+
+	output.Uploads = uploads
+
+	return output, nil
+}
+
+func (f *fakeS3) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, _ ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucketName := aws.ToString(params.Bucket)
+
+	i := slices.IndexFunc(f.multipartUploads[bucketName], func(upload types.MultipartUpload) bool {
+		return aws.ToString(upload.UploadId) == aws.ToString(params.UploadId)
+	})
+	if i < 0 {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	f.multipartUploads[bucketName] = slices.Delete(f.multipartUploads[bucketName], i, i+1)
+
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (f *fakeS3) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.multipartUploads == nil {
+		f.multipartUploads = make(map[string][]types.MultipartUpload)
+	}
+
+	bucketName := aws.ToString(params.Bucket)
+	upload := types.MultipartUpload{
+		Key:       params.Key,
+		UploadId:  aws.String(fmt.Sprintf("upload-%d", len(f.multipartUploads[bucketName])+1)),
+		Initiated: aws.Time(time.Now()),
+	}
+	f.multipartUploads[bucketName] = append(f.multipartUploads[bucketName], upload)
+
+	if f.multipartInputs == nil {
+		f.multipartInputs = make(map[string]*s3.CreateMultipartUploadInput)
+	}
+
+	f.multipartInputs[aws.ToString(upload.UploadId)] = params
+
+	return &s3.CreateMultipartUploadOutput{
+		Bucket:   params.Bucket,
+		Key:      params.Key,
+		UploadId: upload.UploadId,
+	}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, _ ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.uploadedParts == nil {
+		f.uploadedParts = make(map[string]map[int32][]byte)
+	}
+
+	uploadID := aws.ToString(params.UploadId)
+	if f.uploadedParts[uploadID] == nil {
+		f.uploadedParts[uploadID] = make(map[int32][]byte)
+	}
+
+	f.uploadedParts[uploadID][aws.ToInt32(params.PartNumber)] = body
+
+	sum := md5.Sum(body)
+
+	return &s3.UploadPartOutput{
+		ETag: aws.String(`"` + hex.EncodeToString(sum[:]) + `"`),
+	}, nil
+}
+
+// UploadPartCopy copies the range of the source to the part, clamped to the size of the source,
+// as the tests of copies larger than 5 GiB fake the source size with headSizeDelta.
+func (f *fakeS3) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, _ ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	srcBucket, srcKey, _ := strings.Cut(aws.ToString(params.CopySource), "/")
+
+	srcKey, err := url.PathUnescape(srcKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var start, end int64
+
+	if _, err := fmt.Sscanf(aws.ToString(params.CopySourceRange), "bytes=%d-%d", &start, &end); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	object, ok := f.objects[srcBucket][srcKey]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	size := int64(len(object.body))
+	body := object.body[min(start, size):min(end+1, size)]
+
+	if f.uploadedParts == nil {
+		f.uploadedParts = make(map[string]map[int32][]byte)
+	}
+
+	uploadID := aws.ToString(params.UploadId)
+	if f.uploadedParts[uploadID] == nil {
+		f.uploadedParts[uploadID] = make(map[int32][]byte)
+	}
+
+	f.uploadedParts[uploadID][aws.ToInt32(params.PartNumber)] = body
+
+	sum := md5.Sum(body)
+	result := &types.CopyPartResult{
+		ETag: aws.String(`"` + hex.EncodeToString(sum[:]) + `"`),
+	}
+
+	if input := f.multipartInputs[uploadID]; input != nil && input.ChecksumAlgorithm == types.ChecksumAlgorithmSha256 {
+		sum := sha256.Sum256(body)
+		result.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	return &s3.UploadPartCopyOutput{CopyPartResult: result}, nil
+}
+
+// CompleteMultipartUpload stores the concatenated parts with an ETag in the multipart format, the MD5
+// of the part MD5s suffixed with the number of parts.
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, _ ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucketName := aws.ToString(params.Bucket)
+	uploadID := aws.ToString(params.UploadId)
+
+	i := slices.IndexFunc(f.multipartUploads[bucketName], func(upload types.MultipartUpload) bool {
+		return aws.ToString(upload.UploadId) == uploadID
+	})
+	if i < 0 {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	existing, exists := f.objects[bucketName][aws.ToString(params.Key)]
+	if params.IfNoneMatch != nil && exists || params.IfMatch != nil && (!exists || aws.ToString(params.IfMatch) != existing.etag) {
+		return nil, errPreconditionFailed
+	}
+
+	createInput := f.multipartInputs[uploadID]
+	withSHA256 := createInput != nil && createInput.ChecksumAlgorithm == types.ChecksumAlgorithmSha256
+
+	var body, sums, checksums []byte
+
+	for _, part := range params.MultipartUpload.Parts {
+		partBody := f.uploadedParts[uploadID][aws.ToInt32(part.PartNumber)]
+		sum := md5.Sum(partBody)
+		body = append(body, partBody...)
+		sums = append(sums, sum[:]...)
+
+		if withSHA256 {
+			// S3 rejects parts without the checksum of the algorithm of the upload.
+			checksum, err := base64.StdEncoding.DecodeString(aws.ToString(part.ChecksumSHA256))
+			if err != nil || len(checksum) != sha256.Size {
+				return nil, &smithy.GenericAPIError{Code: "InvalidRequest", Message: "The upload was created using a sha256 checksum."}
+			}
+
+			checksums = append(checksums, checksum...)
+		}
+	}
+
+	f.putLocked(bucketName, aws.ToString(params.Key), body)
+
+	sum := md5.Sum(sums)
+	etag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(params.MultipartUpload.Parts))
+
+	object := f.objects[bucketName][aws.ToString(params.Key)]
+	object.etag = etag
+
+	if createInput != nil {
+		object.contentType = aws.ToString(createInput.ContentType)
+		object.cacheControl = aws.ToString(createInput.CacheControl)
+		object.metadata = createInput.Metadata
+
+		if createInput.Tagging != nil {
+			values, err := url.ParseQuery(aws.ToString(createInput.Tagging))
+			if err != nil {
+				return nil, err
+			}
+
+			object.tags = make(map[string]string, len(values))
+			for key := range values {
+				object.tags[key] = values.Get(key)
+			}
+		}
+	}
+
+	output := &s3.CompleteMultipartUploadOutput{
+		Bucket: params.Bucket,
+		Key:    params.Key,
+		ETag:   aws.String(etag),
+	}
+
+	if withSHA256 {
+		sum := sha256.Sum256(checksums)
+		object.checksumSHA256 = fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(sum[:]), len(params.MultipartUpload.Parts))
+		output.ChecksumSHA256 = aws.String(object.checksumSHA256)
+	}
+
+	f.objects[bucketName][aws.ToString(params.Key)] = object
+
+	f.multipartUploads[bucketName] = slices.Delete(f.multipartUploads[bucketName], i, i+1)
+	delete(f.uploadedParts, uploadID)
+
+	return output, nil
+}
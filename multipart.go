@@ -0,0 +1,62 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// AbortIncompleteMultipartUploads aborts the multipart uploads under the prefix initiated more than olderThan ago,
+// deleting their parts, which are billed until the upload is completed or aborted.
+// The threshold should exceed the duration of the longest running upload, as aborting fails it.
+// Failed aborts are joined into the returned error.
+func (s *Client) AbortIncompleteMultipartUploads(ctx context.Context, bucketName string, prefix string, olderThan time.Duration) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if olderThan < 0 {
+		return NewValidationError("age threshold must not be negative")
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	var errs []error
+
+	for {
+		page, err := s.client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return NewS3Error("unable to list multipart uploads", err)
+		}
+
+		for _, upload := range page.Uploads {
+			if !aws.ToTime(upload.Initiated).Before(cutoff) {
+				continue
+			}
+
+			_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucketName),
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			})
+			if err != nil && !isNoSuchUpload(err) {
+				errs = append(errs, NewS3Error("unable to abort multipart upload of "+aws.ToString(upload.Key), err))
+			}
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			return errors.Join(errs...)
+		}
+
+		input.KeyMarker = page.NextKeyMarker
+		input.UploadIdMarker = page.NextUploadIdMarker
+	}
+}
@@ -0,0 +1,44 @@
+package s3utils
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestClient_AbortIncompleteMultipartUploads(t *testing.T) {
+	now := time.Now()
+
+	fake := newFakeS3()
+	fake.pageSize = 2
+	fake.multipartUploads = map[string][]types.MultipartUpload{
+		"bucket": {
+			{Key: aws.String("raw/a.bin"), UploadId: aws.String("1"), Initiated: aws.Time(now.Add(-48 * time.Hour))},
+			{Key: aws.String("raw/a.bin"), UploadId: aws.String("2"), Initiated: aws.Time(now.Add(-time.Hour))},
+			{Key: aws.String("raw/b.bin"), UploadId: aws.String("3"), Initiated: aws.Time(now.Add(-72 * time.Hour))},
+			{Key: aws.String("raw/c.bin"), UploadId: aws.String("4"), Initiated: aws.Time(now.Add(-25 * time.Hour))},
+			{Key: aws.String("other/d.bin"), UploadId: aws.String("5"), Initiated: aws.Time(now.Add(-72 * time.Hour))},
+		},
+	}
+
+	client := newTestClient(t, fake)
+
+	err := client.AbortIncompleteMultipartUploads(context.Background(), "bucket", "raw/", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var remaining []string
+	for _, upload := range fake.multipartUploads["bucket"] {
+		remaining = append(remaining, aws.ToString(upload.UploadId))
+	}
+
+	want := []string{"2", "5"}
+	if !slices.Equal(remaining, want) {
+		t.Errorf("actual remaining uploads `%v` \n expected `%v`", remaining, want)
+	}
+}
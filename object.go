@@ -0,0 +1,173 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectInfo describes an object.
+type ObjectInfo struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ContentType  string    `json:"content_type,omitempty"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	// InvalidUTF8 is set by listings configured with WithInvalidUTF8Keys when the key is not valid UTF-8.
+	InvalidUTF8 bool `json:"invalid_utf8,omitempty"`
+	// Metadata holds the user metadata (x-amz-meta-*) of the object with lowercase keys, as S3 stores them.
+	// It is not set by listings.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Restore is the status of the restore of an archived object. It is set by HeadObject once a restore
+	// was requested with RestoreObject.
+	Restore *RestoreStatus `json:"restore,omitempty"`
+}
+
+// HeadObject returns the object metadata without downloading its content.
+// A missing object results in an error matching ErrObjectNotFound.
+func (s *Client) HeadObject(ctx context.Context, bucketName string, key string) (ObjectInfo, error) {
+	if bucketName == "" {
+		return ObjectInfo{}, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return ObjectInfo{}, NewValidationError("key is empty")
+	}
+
+	key = strings.Trim(key, "/")
+
+	headResp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return ObjectInfo{}, newGetObjectError("unable to get object info", err)
+	}
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(headResp.ContentLength),
+		ContentType:  aws.ToString(headResp.ContentType),
+		ETag:         aws.ToString(headResp.ETag),
+		LastModified: aws.ToTime(headResp.LastModified),
+		Metadata:     normalizeMetadata(headResp.Metadata),
+		Restore:      parseRestore(aws.ToString(headResp.Restore)),
+	}, nil
+}
+
+// ObjectStat reports whether the object exists and its size with a single HeadObject request.
+// A missing object yields (false, 0, nil); other errors are returned.
+func (s *Client) ObjectStat(ctx context.Context, bucketName string, key string) (bool, int64, error) {
+	info, err := s.HeadObject(ctx, bucketName, key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return false, 0, nil
+		}
+
+		return false, 0, err
+	}
+
+	return true, info.Size, nil
+}
+
+func objectInfoFromGetObject(key string, output *s3.GetObjectOutput) ObjectInfo {
+	return ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		ETag:         aws.ToString(output.ETag),
+		LastModified: aws.ToTime(output.LastModified),
+		Metadata:     normalizeMetadata(output.Metadata),
+	}
+}
+
+func objectInfoFromObject(object types.Object) ObjectInfo {
+	return ObjectInfo{
+		Key:          aws.ToString(object.Key),
+		Size:         aws.ToInt64(object.Size),
+		ETag:         aws.ToString(object.ETag),
+		LastModified: aws.ToTime(object.LastModified),
+	}
+}
+
+// normalizeMetadata lowercases the user metadata keys. It returns nil for empty metadata.
+func normalizeMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	normalized := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		normalized[strings.ToLower(key)] = value
+	}
+
+	return normalized
+}
+
+// validMetadataKey reports whether the key is a valid HTTP header field name.
+func validMetadataKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, c := range []byte(key) {
+		isAlphanumeric := c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		if !isAlphanumeric && !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// maxKeyLength is the maximum length of an object key in bytes.
+const maxKeyLength = 1024
+
+// validateObjectKey checks that the key is accepted by S3 and safe to use: it must not be empty,
+// exceed 1024 bytes or contain control characters.
+func validateObjectKey(key string) error {
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if len(key) > maxKeyLength {
+		return NewValidationError(fmt.Sprintf("key is longer than %d bytes: %d", maxKeyLength, len(key)))
+	}
+
+	if strings.ContainsFunc(key, unicode.IsControl) {
+		return NewValidationError(fmt.Sprintf("key contains control characters: %q", key))
+	}
+
+	return nil
+}
+
+// percentEncodeInvalidUTF8 percent-encodes the bytes of the key that are not valid UTF-8 as well as "%" itself,
+// so the result is valid UTF-8 and can be decoded back with url.PathUnescape.
+func percentEncodeInvalidUTF8(key string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, "%%%02X", key[i])
+		case r == '%':
+			b.WriteString("%25")
+		default:
+			b.WriteString(key[i : i+size])
+		}
+
+		i += size
+	}
+
+	return b.String()
+}
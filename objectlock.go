@@ -0,0 +1,58 @@
+package s3utils
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// PutObjectRetention sets the WORM retention of an existing object in the mode, GOVERNANCE or COMPLIANCE,
+// until the retain-until date, which must be in the future. Retention in COMPLIANCE mode can only be extended.
+// The bucket must have object lock enabled, otherwise ErrObjectLockNotEnabled is returned.
+func (s *Client) PutObjectRetention(ctx context.Context, bucketName string, key string, mode types.ObjectLockMode, retainUntil time.Time) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if err := validateRetention(mode, retainUntil); err != nil {
+		return err
+	}
+
+	key = strings.Trim(key, "/")
+
+	_, err := s.client.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Retention: &types.ObjectLockRetention{
+			Mode:            types.ObjectLockRetentionMode(mode),
+			RetainUntilDate: aws.Time(retainUntil),
+		},
+		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
+	})
+	if err != nil {
+		return newPutObjectError("unable to put object retention", err)
+	}
+
+	return nil
+}
+
+func validateRetention(mode types.ObjectLockMode, retainUntil time.Time) error {
+	if !slices.Contains(mode.Values(), mode) {
+		return NewValidationError("unknown object lock mode: " + string(mode))
+	}
+
+	if !retainUntil.After(time.Now()) {
+		return NewValidationError("retain-until date must be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestClient_objectLock(t *testing.T) {
+	fake := newFakeS3()
+	fake.objectLockBuckets = map[string]bool{"locked": true}
+
+	client := newTestClient(t, fake)
+
+	retainUntil := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	_, err := client.UploadReader(context.Background(), "locked", "raw", "data.json", strings.NewReader("1"),
+		WithObjectLock(types.ObjectLockModeCompliance, retainUntil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, _ := fake.get("locked", "raw/data.json")
+	if !object.retainUntil.Equal(retainUntil) {
+		t.Errorf("actual `%v` \n expected `%v`", object.retainUntil, retainUntil)
+	}
+
+	extended := retainUntil.Add(24 * time.Hour)
+
+	err = client.PutObjectRetention(context.Background(), "locked", "raw/data.json", types.ObjectLockModeCompliance, extended)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, _ = fake.get("locked", "raw/data.json")
+	if !object.retainUntil.Equal(extended) {
+		t.Errorf("actual `%v` \n expected `%v`", object.retainUntil, extended)
+	}
+
+	_, err = client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader("1"),
+		WithObjectLock(types.ObjectLockModeGovernance, retainUntil))
+	if !errors.Is(err, ErrObjectLockNotEnabled) {
+		t.Errorf("actual error `%v` \n expected ErrObjectLockNotEnabled", err)
+	}
+
+	fake.put("bucket", "raw/data.json", []byte("1"))
+
+	err = client.PutObjectRetention(context.Background(), "bucket", "raw/data.json", types.ObjectLockModeGovernance, retainUntil)
+	if !errors.Is(err, ErrObjectLockNotEnabled) {
+		t.Errorf("actual error `%v` \n expected ErrObjectLockNotEnabled", err)
+	}
+}
+
+func Test_validateRetention(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        types.ObjectLockMode
+		retainUntil time.Time
+		wantErr     bool
+	}{
+		{
+			name:        "valid",
+			mode:        types.ObjectLockModeGovernance,
+			retainUntil: time.Now().Add(time.Hour),
+		},
+		{
+			name:        "past_date",
+			mode:        types.ObjectLockModeGovernance,
+			retainUntil: time.Now().Add(-time.Hour),
+			wantErr:     true,
+		},
+		{
+			name:        "unknown_mode",
+			mode:        "WORM",
+			retainUntil: time.Now().Add(time.Hour),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRetention(tt.mode, tt.retainUntil); (err != nil) != tt.wantErr {
+				t.Errorf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,1139 @@
+package s3utils
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Option configures a Client created by NewClient or NewClientWithAPI.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	retryMaxAttempts  int
+	retryBaseDelay    time.Duration
+	retryMaxBackoff   time.Duration
+	retryBudget       int
+	deleteWorkers     int
+	operationTimeouts map[string]time.Duration
+	contentTypes      map[string]string
+	logger            LogFunc
+	observer          Observer
+	profile           string
+	credentials       aws.CredentialsProvider
+	assumeRole        *assumeRoleOptions
+	endpointResolver  s3.EndpointResolverV2
+	maxKeys           *int32
+	requesterPays     bool
+	// expectedBucketOwner is the account ID set with WithExpectedBucketOwner.
+	expectedBucketOwner string
+	httpClient          *http.Client
+	region              string
+	detectBucketRegion  bool
+	endpoint            string
+	pathStyle           bool
+	insecure            bool
+	userAgent           string
+	// layout is the key layout of date partitions, nil unless set with WithKeyLayout.
+	layout *KeyLayout
+}
+
+// WithRetry configures the retryer used for all operations.
+// Failed attempts, including throttling responses such as 503 SlowDown, are retried up to maxAttempts in total
+// with an exponential backoff starting at baseDelay and randomized with full jitter.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retryMaxAttempts = maxAttempts
+		o.retryBaseDelay = baseDelay
+	}
+}
+
+// WithBaseRetryDelay sets the delay before the first retry, which doubles with every further retry
+// up to the max backoff and is randomized with full jitter. It overrides the base delay of WithRetry
+// and keeps the number of attempts. It applies to clients created by NewClient.
+func WithBaseRetryDelay(delay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retryBaseDelay = delay
+	}
+}
+
+// WithMaxBackoff caps the delay between retries, 20 seconds by default. It must not be less than the base delay.
+// It applies to clients created by NewClient.
+func WithMaxBackoff(maxBackoff time.Duration) Option {
+	return func(o *clientOptions) {
+		o.retryMaxBackoff = maxBackoff
+	}
+}
+
+// WithRetryBudget limits the total number of retries of all requests made by a single call of a batch method,
+// i.e. a method acting on many objects, such as UploadFiles, CopyObjects, DeleteFolder or SyncToBucket,
+// so a job failing persistently gives up early. Once the budget is consumed, failed requests are not retried
+// and fail with ErrRetryBudgetExhausted. With NewClientFromConfig it limits the retryer of the config.
+// NewClientWithAPI rejects it with a ValidationError.
+func WithRetryBudget(n int) Option {
+	return func(o *clientOptions) {
+		o.retryBudget = n
+	}
+}
+
+// WithDeleteConcurrency sets the number of DeleteObjects requests of up to 1000 keys each sent in parallel
+// by DeleteFolder, DeleteFolderByDate, DeleteObjects, DeleteObjectsVerbose and SyncToBucket, 1 by default.
+// It speeds up the deletion of many objects, at the cost of a higher request rate.
+func WithDeleteConcurrency(n int) Option {
+	return func(o *clientOptions) {
+		o.deleteWorkers = n
+	}
+}
+
+// WithOperationTimeouts sets default timeouts per operation name, e.g. OperationHeadObject.
+// A timeout applies to each call of the operation whose context has no deadline.
+// The timeout of OperationGetObject also covers reading the response body.
+func WithOperationTimeouts(timeouts map[string]time.Duration) Option {
+	return func(o *clientOptions) {
+		o.operationTimeouts = timeouts
+	}
+}
+
+// WithContentTypeMap registers content types by file extension, e.g. ".ndjson": "application/x-ndjson".
+// Uploads set the Content-Type from the extension of the object key,
+// consulting this map before the standard MIME types. Extensions are matched case-insensitively.
+func WithContentTypeMap(contentTypes map[string]string) Option {
+	return func(o *clientOptions) {
+		if o.contentTypes == nil {
+			o.contentTypes = make(map[string]string, len(contentTypes))
+		}
+
+		for ext, contentType := range contentTypes {
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+
+			o.contentTypes[strings.ToLower(ext)] = contentType
+		}
+	}
+}
+
+// Log levels passed to LogFunc.
+const (
+	LogLevelDebug = "debug"
+	LogLevelError = "error"
+)
+
+// LogFunc receives a log record with alternating key-value pairs, as accepted by slog.Logger.Log.
+type LogFunc func(level string, msg string, keyvals ...any)
+
+// WithLogger logs every S3 request before and after it is made, with the operation, bucket, key and duration.
+// Failed requests are logged with LogLevelError. A nil logger disables logging.
+func WithLogger(logger LogFunc) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// Observer receives the outcome of every S3 request, e.g. to record metrics.
+type Observer interface {
+	// ObserveOperation is called after a request of the operation, e.g. OperationPutObject, is done.
+	// The duration of OperationGetObject includes reading the response body.
+	ObserveOperation(operation string, duration time.Duration, err error)
+}
+
+// WithObserver reports every S3 request to the observer. A nil observer disables reporting.
+func WithObserver(observer Observer) Option {
+	return func(o *clientOptions) {
+		o.observer = observer
+	}
+}
+
+// WithProfile selects the named profile of the shared AWS config and credentials files, e.g. ~/.aws/config.
+// It can't be combined with WithCredentials.
+func WithProfile(name string) Option {
+	return func(o *clientOptions) {
+		o.profile = name
+	}
+}
+
+// WithCredentials sets the credentials provider used instead of the default credential chain.
+// It can't be combined with WithProfile.
+func WithCredentials(provider aws.CredentialsProvider) Option {
+	return func(o *clientOptions) {
+		o.credentials = provider
+	}
+}
+
+// WithEndpointResolver replaces the resolution of S3 endpoints, e.g. to target a partition or
+// an S3-compatible store with custom addressing. By default endpoints are resolved from the client region,
+// which also covers the GovCloud and China partitions.
+func WithEndpointResolver(resolver s3.EndpointResolverV2) Option {
+	return func(o *clientOptions) {
+		o.endpointResolver = resolver
+	}
+}
+
+// WithEndpoint sets the base endpoint of the S3 requests, e.g. `https://minio.internal:9000` to target
+// an S3-compatible store. An endpoint without a scheme uses HTTPS, or HTTP with WithInsecure.
+// Most S3-compatible stores also require WithPathStyle. It applies to clients created by NewClient.
+func WithEndpoint(endpoint string) Option {
+	return func(o *clientOptions) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithPathStyle addresses buckets in the path of the request URL instead of the host name,
+// as required by most S3-compatible stores. It applies to clients created by NewClient.
+func WithPathStyle() Option {
+	return func(o *clientOptions) {
+		o.pathStyle = true
+	}
+}
+
+// WithInsecure disables the verification of TLS certificates and makes an endpoint without a scheme
+// use plain HTTP, e.g. to target a local MinIO container. It is meant for testing only: it exposes the traffic,
+// including the credentials, to interception. It requires WithEndpoint and can't be combined with WithHTTPClient.
+// It applies to clients created by NewClient.
+func WithInsecure() Option {
+	return func(o *clientOptions) {
+		o.insecure = true
+	}
+}
+
+// WithUserAgent adds the suffix to the User-Agent header of the SDK requests, e.g. the application name,
+// to attribute the S3 usage in CloudTrail and the S3 access logs. Characters not allowed in the header
+// are replaced with a dash by the SDK. It applies to clients created by NewClient and NewClientFromConfig.
+func WithUserAgent(suffix string) Option {
+	return func(o *clientOptions) {
+		o.userAgent = suffix
+	}
+}
+
+// WithKeyLayout sets the layout of the date partitions in the keys written by UploadFileWithDateDestination,
+// deleted by DeleteFolderByDate and parsed by ListByDatePartition, e.g. for tools that can't parse
+// the default _year= levels.
+func WithKeyLayout(layout KeyLayout) Option {
+	return func(o *clientOptions) {
+		o.layout = &layout
+	}
+}
+
+// WithRegion sets the region of the client, overriding the region passed to the constructor,
+// e.g. when the options are assembled from configuration.
+func WithRegion(region string) Option {
+	return func(o *clientOptions) {
+		o.region = region
+	}
+}
+
+// WithBucketRegionDetection routes the operations on a bucket to the region where the bucket is located,
+// which requests to another region fail with a 301 PermanentRedirect. The region of each bucket is looked up
+// with GetBucketLocation on first use and cached by the client; if the lookup fails, the client region is used
+// for a minute before the lookup is retried.
+// WithOperationRegion takes precedence. NewClientWithAPI rejects it unless the API is an *s3.Client.
+func WithBucketRegionDetection() Option {
+	return func(o *clientOptions) {
+		o.detectBucketRegion = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the SDK, e.g. to route the traffic through a proxy, trust a custom CA bundle
+// or tune the connection pool. It applies to clients created by NewClient.
+// The SDK can't add the CA bundle of AWS_CA_BUNDLE or the shared config to a plain *http.Client,
+// so loading the config fails if one is set; configure the bundle in the client transport instead.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = client
+	}
+}
+
+// WithRequesterPays confirms that the requester pays for the reads of objects and listings, as required by
+// requester-pays buckets, which otherwise reject the requests with AccessDenied. The request and data transfer
+// charges of these reads are billed to the AWS account of the client credentials instead of the bucket owner.
+// It applies to GetObject, HeadObject, GetObjectAttributes and ListObjectsV2 requests.
+func WithRequesterPays() Option {
+	return func(o *clientOptions) {
+		o.requesterPays = true
+	}
+}
+
+// WithExpectedBucketOwner makes S3 reject the object reads, writes, listings and deletions with 403 AccessDenied
+// unless the bucket is owned by the AWS account with the 12-digit ID, e.g. to guard cross-account uploads
+// against a bucket deleted and recreated by another account. Use a separate client for buckets of other owners.
+// It applies to every request on a bucket, and to the source bucket of copies.
+func WithExpectedBucketOwner(accountID string) Option {
+	return func(o *clientOptions) {
+		o.expectedBucketOwner = accountID
+	}
+}
+
+// maxListKeys is the maximum number of keys returned by a single ListObjectsV2 request.
+const maxListKeys = 1000
+
+// WithMaxKeys sets the number of keys requested per ListObjectsV2 page by listings and folder deletions,
+// from 1 to 1000, trading memory for the number of requests. S3 returns 1000 keys per page by default.
+func WithMaxKeys(n int32) Option {
+	return func(o *clientOptions) {
+		o.maxKeys = aws.Int32(n)
+	}
+}
+
+func newClientOptions(opts []Option) (clientOptions, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.retryMaxAttempts < 0 {
+		return o, NewValidationError("retry max attempts must not be negative")
+	}
+
+	if o.retryMaxAttempts > 0 && o.retryBaseDelay <= 0 {
+		return o, NewValidationError("retry base delay must be positive")
+	}
+
+	if o.retryBudget < 0 {
+		return o, NewValidationError("retry budget must not be negative")
+	}
+
+	if o.deleteWorkers < 0 {
+		return o, NewValidationError("delete concurrency must not be negative")
+	}
+
+	if o.retryBaseDelay < 0 {
+		return o, NewValidationError("retry base delay must not be negative")
+	}
+
+	if o.retryMaxBackoff < 0 {
+		return o, NewValidationError("max backoff must not be negative")
+	}
+
+	if o.retryMaxBackoff > 0 && o.retryMaxBackoff < o.retryBaseDelay {
+		return o, NewValidationError("max backoff must not be less than the retry base delay")
+	}
+
+	for operation, timeout := range o.operationTimeouts {
+		if !slices.Contains(operations, operation) {
+			return o, NewValidationError("unknown operation: " + operation)
+		}
+
+		if timeout <= 0 {
+			return o, NewValidationError("timeout of " + operation + " must be positive")
+		}
+	}
+
+	if o.expectedBucketOwner != "" && !isAccountID(o.expectedBucketOwner) {
+		return o, NewValidationError("expected bucket owner must be a 12-digit account ID: " + o.expectedBucketOwner)
+	}
+
+	if o.maxKeys != nil && (*o.maxKeys < 1 || *o.maxKeys > maxListKeys) {
+		return o, NewValidationError("max keys must be between 1 and 1000")
+	}
+
+	if o.profile != "" && o.credentials != nil {
+		return o, NewValidationError("profile and credentials are mutually exclusive")
+	}
+
+	if o.assumeRole != nil {
+		if err := o.assumeRole.validate(); err != nil {
+			return o, err
+		}
+	}
+
+	if o.endpoint != "" {
+		if _, err := url.Parse(o.endpointURL()); err != nil {
+			return o, NewValidationError("invalid endpoint: " + err.Error())
+		}
+	}
+
+	if o.layout != nil {
+		if err := o.layout.validate(); err != nil {
+			return o, err
+		}
+	}
+
+	if o.insecure && o.endpoint == "" {
+		return o, NewValidationError("insecure requires an endpoint")
+	}
+
+	if o.insecure && o.httpClient != nil {
+		return o, NewValidationError("insecure and HTTP client are mutually exclusive")
+	}
+
+	return o, nil
+}
+
+// loadOptions returns the options passed to config.LoadDefaultConfig.
+func (o clientOptions) loadOptions() []func(*config.LoadOptions) error {
+	var loadOptions []func(*config.LoadOptions) error
+
+	if o.retryMaxAttempts > 0 || o.retryBaseDelay > 0 || o.retryMaxBackoff > 0 || o.retryBudget > 0 {
+		loadOptions = append(loadOptions, config.WithRetryer(o.retryer))
+	}
+
+	if o.profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(o.profile))
+	}
+
+	if o.credentials != nil {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(o.credentials))
+	}
+
+	if o.httpClient != nil {
+		loadOptions = append(loadOptions, config.WithHTTPClient(o.httpClient))
+	}
+
+	if o.insecure {
+		loadOptions = append(loadOptions, config.WithHTTPClient(awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+			if tr.TLSClientConfig == nil {
+				tr.TLSClientConfig = &tls.Config{}
+			}
+
+			tr.TLSClientConfig.InsecureSkipVerify = true
+		})))
+	}
+
+	return loadOptions
+}
+
+// bucketOwner returns the account ID set with WithExpectedBucketOwner, nil if it is not set.
+func (o clientOptions) bucketOwner() *string {
+	if o.expectedBucketOwner == "" {
+		return nil
+	}
+
+	return aws.String(o.expectedBucketOwner)
+}
+
+// isAccountID reports whether the string is a 12-digit AWS account ID.
+func isAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deleteConcurrency returns the number of parallel DeleteObjects requests set with WithDeleteConcurrency.
+func (o clientOptions) deleteConcurrency() int {
+	return max(o.deleteWorkers, 1)
+}
+
+// keyLayout returns the key layout set with WithKeyLayout or DefaultKeyLayout.
+func (o clientOptions) keyLayout() KeyLayout {
+	if o.layout == nil {
+		return DefaultKeyLayout()
+	}
+
+	return *o.layout
+}
+
+// endpointURL returns the endpoint set with WithEndpoint, with the scheme added if it has none.
+func (o clientOptions) endpointURL() string {
+	if strings.Contains(o.endpoint, "://") {
+		return o.endpoint
+	}
+
+	if o.insecure {
+		return "http://" + o.endpoint
+	}
+
+	return "https://" + o.endpoint
+}
+
+// s3Options returns the options passed to s3.NewFromConfig.
+func (o clientOptions) s3Options() []func(*s3.Options) {
+	var s3Options []func(*s3.Options)
+
+	if o.endpointResolver != nil {
+		s3Options = append(s3Options, func(so *s3.Options) {
+			so.EndpointResolverV2 = o.endpointResolver
+		})
+	}
+
+	if o.endpoint != "" {
+		s3Options = append(s3Options, func(so *s3.Options) {
+			so.BaseEndpoint = aws.String(o.endpointURL())
+		})
+	}
+
+	if o.pathStyle {
+		s3Options = append(s3Options, func(so *s3.Options) {
+			so.UsePathStyle = true
+		})
+	}
+
+	if o.userAgent != "" {
+		s3Options = append(s3Options, func(so *s3.Options) {
+			so.APIOptions = append(so.APIOptions, awsmiddleware.AddUserAgentKey(o.userAgent))
+		})
+	}
+
+	return s3Options
+}
+
+func (o clientOptions) retryer() aws.Retryer {
+	return newRetryer(o.retryMaxAttempts, o.retryBaseDelay, o.retryMaxBackoff)
+}
+
+// UploadOption configures a single upload.
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	preserveSlashes bool
+	backupSuffix    string
+	storageClass    types.StorageClass
+	gzip            bool
+	contentMD5      bool
+	verifyETag      bool
+	prettyJSON      bool
+	verifyUpload    bool
+	// cacheControl and contentDisposition are nil unless set by their options.
+	cacheControl       *string
+	contentDisposition *string
+	metadata           map[string]string
+	tags               map[string]string
+	encryptionContext  map[string]string
+	kmsKeyID           *string
+	partitionMarker    string
+	relativePath       bool
+	objectLockMode     types.ObjectLockMode
+	// objectLockRetainUntil is the retain-until date of the object lock, validated against the time of the upload.
+	objectLockRetainUntil time.Time
+	// contentType overrides the content type detected from the object key.
+	contentType  string
+	atomicUpload bool
+	allowEmpty   bool
+	acl          types.ObjectCannedACL
+	// ifMatch and ifNoneMatch are the conditions of the write, nil unless set by their options.
+	ifMatch     *string
+	ifNoneMatch *string
+	// checksumAlgorithm is the flexible checksum computed by the SDK and verified by S3.
+	checksumAlgorithm types.ChecksumAlgorithm
+	// contentLength is the length of the body of UploadReader, nil unless set with WithContentLength.
+	contentLength *int64
+}
+
+// PreserveSlashes disables trimming of leading and trailing slashes from the directory in UploadFileBase,
+// UploadReader and UploadJSON, so the directory is used in the object key exactly as given.
+// It is useful for S3-compatible stores that treat a leading slash literally.
+// UploadFileWithDateDestination rejects it with a ValidationError.
+func PreserveSlashes() UploadOption {
+	return func(o *uploadOptions) {
+		o.preserveSlashes = true
+	}
+}
+
+// WithBackupOnOverwrite copies an existing object to a backup key before it is overwritten.
+// The backup key is the object key with the suffix appended; an empty suffix defaults to ".bak".
+// No backup is made if the object doesn't exist yet.
+func WithBackupOnOverwrite(suffix string) UploadOption {
+	return func(o *uploadOptions) {
+		if suffix == "" {
+			suffix = ".bak"
+		}
+
+		o.backupSuffix = suffix
+	}
+}
+
+// WithStorageClass sets the storage class of the uploaded object, e.g. types.StorageClassStandardIa.
+func WithStorageClass(storageClass types.StorageClass) UploadOption {
+	return func(o *uploadOptions) {
+		o.storageClass = storageClass
+	}
+}
+
+// WithGzip compresses the object with gzip while it is uploaded.
+// The ".gz" extension is appended to the object key and the Content-Encoding is set to gzip.
+func WithGzip() UploadOption {
+	return func(o *uploadOptions) {
+		o.gzip = true
+	}
+}
+
+// WithContentMD5 computes the MD5 of the file and sends it in the Content-MD5 header,
+// so S3 rejects the upload if the received bytes differ.
+// Streamed uploads, whose content can't be hashed before it is sent, are rejected with a ValidationError.
+// This includes WithGzip and readers that aren't seekable or whose length isn't set with WithContentLength.
+func WithContentMD5() UploadOption {
+	return func(o *uploadOptions) {
+		o.contentMD5 = true
+	}
+}
+
+// WithETagVerification compares the ETag returned by S3 with the MD5 of the uploaded content
+// and returns ErrChecksumMismatch on mismatch.
+// Multipart uploads are not verified, since their ETag is not a plain MD5.
+// Don't use it with SSE-KMS or SSE-C encryption, for which the ETag is not the MD5 of the content either.
+func WithETagVerification() UploadOption {
+	return func(o *uploadOptions) {
+		o.verifyETag = true
+	}
+}
+
+// WithChecksumAlgorithm makes the SDK compute a flexible checksum of the uploaded content, e.g. CRC32C or SHA256,
+// which S3 verifies on receipt and stores with the object. Unlike the ETag it also covers multipart uploads.
+// The checksum returned by S3 is reported in UploadInfo.
+func WithChecksumAlgorithm(algorithm types.ChecksumAlgorithm) UploadOption {
+	return func(o *uploadOptions) {
+		o.checksumAlgorithm = algorithm
+	}
+}
+
+// VerifyAfterUpload reads the object info back after the upload and returns ErrVerificationFailed
+// if the size or the ETag of the object differ from the uploaded ones.
+// Objects that are not visible yet are looked up again a few times.
+func VerifyAfterUpload() UploadOption {
+	return func(o *uploadOptions) {
+		o.verifyUpload = true
+	}
+}
+
+// WithContentLength sets the length of the body of UploadReader, which must provide exactly size bytes.
+// A seekable body, e.g. a bytes.Reader or an os.File, of a known length up to 5 GiB is sent in a single
+// PutObject request with the Content-Length header, without buffering it in parts. Other bodies are uploaded
+// through the upload manager, since the SDK can't rewind them to sign or retry the request.
+// It can't be combined with WithGzip, which changes the length of the body. It applies to UploadReader only,
+// the other upload methods reject it with a ValidationError.
+func WithContentLength(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.contentLength = aws.Int64(size)
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of the uploaded object, e.g. "max-age=3600".
+func WithCacheControl(cacheControl string) UploadOption {
+	return func(o *uploadOptions) {
+		o.cacheControl = &cacheControl
+	}
+}
+
+// WithContentDisposition sets the Content-Disposition header of the uploaded object,
+// e.g. "attachment; filename=report.csv".
+func WithContentDisposition(contentDisposition string) UploadOption {
+	return func(o *uploadOptions) {
+		o.contentDisposition = &contentDisposition
+	}
+}
+
+// WithMetadata sets the user metadata of the uploaded object, sent as x-amz-meta-* headers.
+// S3 stores the keys in lowercase, so they are returned lowercased by HeadObject and GetObjectWithHeaders.
+// Keys must be valid HTTP header field names.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithEncryptionContext encrypts the uploaded object with SSE-KMS using the KMS key, given by its ID or ARN,
+// under the encryption context, as required by key policies with encryption context conditions.
+// The context is sent base64-encoded as JSON. S3 stores the key and the context with the object,
+// so downloads don't need them.
+func WithEncryptionContext(keyID string, encryptionContext map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.kmsKeyID = aws.String(keyID)
+		o.encryptionContext = encryptionContext
+	}
+}
+
+// maxObjectTags is the maximum number of tags of an object.
+const maxObjectTags = 10
+
+// WithTags sets the tags of the uploaded object, e.g. to select objects in lifecycle rules.
+// S3 allows up to 10 tags with keys of up to 128 and values of up to 256 characters.
+func WithTags(tags map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.tags = tags
+	}
+}
+
+// WithPartitionMarker makes UploadFileWithDateDestination create an empty marker object with the name
+// in the date partition after the data upload, as expected by Hive-style tools.
+// An empty name defaults to "_SUCCESS". The marker gets the storage class, encryption, metadata and tags
+// of the upload, but not its conditions such as WithCreateOnly. The other upload methods reject it
+// with a ValidationError.
+func WithPartitionMarker(name string) UploadOption {
+	return func(o *uploadOptions) {
+		if name == "" {
+			name = "_SUCCESS"
+		}
+
+		o.partitionMarker = name
+	}
+}
+
+// WithRelativePath makes UploadFileWithDateDestination place the whole relative file path in the date partition,
+// e.g. "region/us/data.csv", instead of only the file name. The file path must be relative
+// and must not leave the working directory. The other upload methods reject it with a ValidationError.
+func WithRelativePath() UploadOption {
+	return func(o *uploadOptions) {
+		o.relativePath = true
+	}
+}
+
+// WithObjectLock uploads the object with a WORM retention in the mode, GOVERNANCE or COMPLIANCE,
+// until the retain-until date, which must be in the future.
+// The bucket must have object lock enabled, otherwise the upload fails with ErrObjectLockNotEnabled.
+func WithObjectLock(mode types.ObjectLockMode, retainUntil time.Time) UploadOption {
+	return func(o *uploadOptions) {
+		o.objectLockMode = mode
+		o.objectLockRetainUntil = retainUntil
+	}
+}
+
+// WithPrettyJSON indents the JSON uploaded by UploadJSON.
+func WithPrettyJSON() UploadOption {
+	return func(o *uploadOptions) {
+		o.prettyJSON = true
+	}
+}
+
+// WithAtomicUpload uploads to a temporary key next to the destination, then copies the object to the destination
+// and deletes the temporary one, so the destination only appears in listings once the upload and its verification
+// succeeded. A single PUT is already atomic, so this matters for the ordering of multipart uploads and of what
+// consumers watching the listing see. It can't be combined with WithObjectLock, as the retention isn't copied.
+func WithAtomicUpload() UploadOption {
+	return func(o *uploadOptions) {
+		o.atomicUpload = true
+	}
+}
+
+// WithAllowEmpty permits uploading empty files, e.g. to create marker objects.
+// By default empty files are rejected with a ValidationError, as they usually indicate a failed export.
+func WithAllowEmpty() UploadOption {
+	return func(o *uploadOptions) {
+		o.allowEmpty = true
+	}
+}
+
+// WithACL sets the canned ACL of the uploaded object, e.g. types.ObjectCannedACLBucketOwnerFullControl.
+// It only works on buckets with ACLs enabled; buckets with the BucketOwnerEnforced object ownership setting
+// reject uploads with ACLs other than bucket-owner-full-control.
+func WithACL(acl types.ObjectCannedACL) UploadOption {
+	return func(o *uploadOptions) {
+		o.acl = acl
+	}
+}
+
+// WithIfMatch makes the upload conditional: it only overwrites the object if its ETag still equals etag,
+// e.g. as read by HeadObject, so concurrent writers don't lose updates. Otherwise the upload fails
+// with ErrPreconditionFailed, and also if the object doesn't exist.
+func WithIfMatch(etag string) UploadOption {
+	return func(o *uploadOptions) {
+		o.ifMatch = aws.String(etag)
+	}
+}
+
+// WithCreateOnly makes the upload conditional with If-None-Match: *: it only creates the object if the key
+// doesn't exist yet, otherwise it fails with ErrPreconditionFailed, also matching ErrObjectExists.
+// S3 evaluates the condition atomically when the object is created, so there is no race window with
+// concurrent writers, unlike a HeadObject check before the upload. Multipart uploads are checked
+// on completion, after all parts are sent.
+func WithCreateOnly() UploadOption {
+	return func(o *uploadOptions) {
+		o.ifNoneMatch = aws.String("*")
+	}
+}
+
+// WithNoOverwrite protects existing objects: the upload fails with ErrObjectExists if the key is present.
+//
+// Deprecated: WithNoOverwrite is an alias of WithCreateOnly, use WithCreateOnly instead.
+func WithNoOverwrite() UploadOption {
+	return WithCreateOnly()
+}
+
+func newUploadOptions(opts []UploadOption) (uploadOptions, error) {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.storageClass != "" && !slices.Contains(o.storageClass.Values(), o.storageClass) {
+		return o, NewValidationError("unknown storage class: " + string(o.storageClass))
+	}
+
+	if o.acl != "" && !slices.Contains(o.acl.Values(), o.acl) {
+		return o, NewValidationError("unknown canned ACL: " + string(o.acl))
+	}
+
+	if o.checksumAlgorithm != "" && !slices.Contains(o.checksumAlgorithm.Values(), o.checksumAlgorithm) {
+		return o, NewValidationError("unknown checksum algorithm: " + string(o.checksumAlgorithm))
+	}
+
+	if o.cacheControl != nil && strings.TrimSpace(*o.cacheControl) == "" {
+		return o, NewValidationError("cache control is empty")
+	}
+
+	if o.contentDisposition != nil && strings.TrimSpace(*o.contentDisposition) == "" {
+		return o, NewValidationError("content disposition is empty")
+	}
+
+	if o.contentLength != nil && *o.contentLength < 0 {
+		return o, NewValidationError("content length must not be negative")
+	}
+
+	if o.contentLength != nil && o.gzip {
+		return o, NewValidationError("content length can't be combined with gzip")
+	}
+
+	if o.kmsKeyID != nil && strings.TrimSpace(*o.kmsKeyID) == "" {
+		return o, NewValidationError("KMS key ID is empty")
+	}
+
+	for key := range o.encryptionContext {
+		if key == "" {
+			return o, NewValidationError("encryption context key is empty")
+		}
+	}
+
+	if len(o.tags) > maxObjectTags {
+		return o, NewValidationError("too many tags")
+	}
+
+	for key, value := range o.tags {
+		if key == "" {
+			return o, NewValidationError("tag key is empty")
+		}
+
+		if utf8.RuneCountInString(key) > 128 || utf8.RuneCountInString(value) > 256 {
+			return o, NewValidationError("tag is too long: " + key)
+		}
+	}
+
+	if strings.Contains(o.partitionMarker, "/") {
+		return o, NewValidationError("partition marker name must not contain a slash")
+	}
+
+	if o.atomicUpload && o.objectLockMode != "" {
+		return o, NewValidationError("atomic upload can't be combined with object lock")
+	}
+
+	if o.ifMatch != nil && strings.TrimSpace(*o.ifMatch) == "" {
+		return o, NewValidationError("if-match etag is empty")
+	}
+
+	if o.ifMatch != nil && o.ifNoneMatch != nil {
+		return o, NewValidationError("if-match and create-only are mutually exclusive")
+	}
+
+	if o.atomicUpload && (o.ifMatch != nil || o.ifNoneMatch != nil) {
+		return o, NewValidationError("atomic upload can't be combined with conditional writes")
+	}
+
+	if o.objectLockMode != "" {
+		if err := validateRetention(o.objectLockMode, o.objectLockRetainUntil); err != nil {
+			return o, err
+		}
+	}
+
+	for key := range o.metadata {
+		if !validMetadataKey(key) {
+			return o, NewValidationError("invalid metadata key: " + key)
+		}
+	}
+
+	return o, nil
+}
+
+// DownloadOption configures a single download.
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	decompress      bool
+	maxSize         int64
+	ifModifiedSince time.Time
+	ifNoneMatch     string
+	// partSize and concurrency configure the parallel download of DownloadLargeObject.
+	partSize    int64
+	concurrency int
+	verifyETag  bool
+}
+
+// WithDecompress decompresses objects stored with gzip Content-Encoding, e.g. uploaded with WithGzip.
+// Objects with another encoding are downloaded as is. DownloadLargeObject rejects it with a ValidationError.
+func WithDecompress() DownloadOption {
+	return func(o *downloadOptions) {
+		o.decompress = true
+	}
+}
+
+// WithMaxSize limits the number of bytes read into memory by GetObjectBytes.
+// Larger objects are rejected with ErrObjectTooLarge. The default is DefaultMaxObjectBytes.
+func WithMaxSize(maxSize int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.maxSize = maxSize
+	}
+}
+
+// WithIfModifiedSince makes the download conditional: if the object has not been modified since t,
+// the download fails with ErrNotModified.
+func WithIfModifiedSince(t time.Time) DownloadOption {
+	return func(o *downloadOptions) {
+		o.ifModifiedSince = t
+	}
+}
+
+// WithIfNoneMatch makes the download conditional: if the object ETag still equals etag,
+// the download fails with ErrNotModified.
+func WithIfNoneMatch(etag string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.ifNoneMatch = etag
+	}
+}
+
+// WithDownloadETagVerification compares the MD5 of the downloaded content with the ETag of the object
+// and fails with ErrChecksumMismatch on mismatch, leaving no file behind. It applies to GetObject, GetObjectWithResult,
+// GetObjectBytes, GetObjectJSON and GetObjectToWriter, which reports the mismatch once the content is written to w.
+// DownloadLargeObject and GetObjectWithHeaders reject it with a ValidationError.
+// Objects uploaded in parts are not verified, since their ETag is not a plain MD5.
+// Don't use it with SSE-KMS or SSE-C encryption, for which the ETag is not the MD5 of the content either.
+func WithDownloadETagVerification() DownloadOption {
+	return func(o *downloadOptions) {
+		o.verifyETag = true
+	}
+}
+
+// WithPartSize sets the size of the ranges downloaded in parallel by DownloadLargeObject.
+// The default is manager.DefaultDownloadPartSize (5 MiB).
+func WithPartSize(partSize int64) DownloadOption {
+	return func(o *downloadOptions) {
+		o.partSize = partSize
+	}
+}
+
+// WithConcurrency sets the number of ranges downloaded in parallel by DownloadLargeObject.
+// The default is manager.DefaultDownloadConcurrency (5).
+func WithConcurrency(concurrency int) DownloadOption {
+	return func(o *downloadOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+func newDownloadOptions(opts []DownloadOption) downloadOptions {
+	o := downloadOptions{
+		maxSize: DefaultMaxObjectBytes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// ListOption configures a single listing.
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	invalidKeyHandling InvalidKeyHandling
+}
+
+// InvalidKeyHandling defines how listings treat object keys that are not valid UTF-8.
+type InvalidKeyHandling int
+
+const (
+	// InvalidKeyKeep returns keys as is. This is the default.
+	InvalidKeyKeep InvalidKeyHandling = iota
+	// InvalidKeyFlag returns keys as is and sets ObjectInfo.InvalidUTF8.
+	InvalidKeyFlag
+	// InvalidKeyPercentEncode percent-encodes the invalid bytes and "%" in the key and sets ObjectInfo.InvalidUTF8.
+	// The original key can be restored with url.PathUnescape.
+	InvalidKeyPercentEncode
+)
+
+// WithInvalidUTF8Keys configures how keys that are not valid UTF-8 are returned,
+// since such keys are silently corrupted by JSON marshalling.
+func WithInvalidUTF8Keys(handling InvalidKeyHandling) ListOption {
+	return func(o *listOptions) {
+		o.invalidKeyHandling = handling
+	}
+}
+
+func newListOptions(opts []ListOption) listOptions {
+	var o listOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// CopyOption configures a single copy.
+type CopyOption func(*copyOptions)
+
+type copyOptions struct {
+	// replaceMetadata switches the metadata directive from COPY to REPLACE.
+	replaceMetadata bool
+	contentType     *string
+	metadata        map[string]string
+	// storageClass is the storage class of the copy, STANDARD if empty.
+	storageClass types.StorageClass
+	// acl is the canned ACL of the copy, private if empty.
+	acl types.ObjectCannedACL
+	// serverSideEncryption, kmsKeyID and encryptionContext are the SSE-KMS settings of the copy,
+	// which uses the default encryption of the bucket if they are empty.
+	serverSideEncryption types.ServerSideEncryption
+	kmsKeyID             *string
+	encryptionContext    *string
+	// checksumAlgorithm is the checksum algorithm of the copy. Multipart copies use the one of the source if it is empty.
+	checksumAlgorithm types.ChecksumAlgorithm
+}
+
+// WithReplaceMetadata replaces the user metadata of the copy instead of copying it from the source.
+// Replacing also resets the content type unless it is set with WithReplaceContentType.
+func WithReplaceMetadata(metadata map[string]string) CopyOption {
+	return func(o *copyOptions) {
+		o.replaceMetadata = true
+		o.metadata = metadata
+	}
+}
+
+// WithReplaceContentType replaces the content type of the copy instead of copying it from the source.
+// Replacing also drops the user metadata unless it is set with WithReplaceMetadata.
+func WithReplaceContentType(contentType string) CopyOption {
+	return func(o *copyOptions) {
+		o.replaceMetadata = true
+		o.contentType = aws.String(contentType)
+	}
+}
+
+func newCopyOptions(opts []CopyOption) copyOptions {
+	var o copyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// DeleteOption configures a single delete.
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	hardDelete bool
+}
+
+// HardDelete permanently deletes all versions and delete markers of the object in a versioned bucket,
+// instead of adding a delete marker. In an unversioned bucket the object is deleted normally.
+func HardDelete() DeleteOption {
+	return func(o *deleteOptions) {
+		o.hardDelete = true
+	}
+}
+
+func newDeleteOptions(opts []DeleteOption) deleteOptions {
+	var o deleteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// SyncOption configures SyncToBucket.
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	deleteRemoved bool
+}
+
+// WithDeleteRemoved deletes the objects under the prefix that have no matching local file.
+func WithDeleteRemoved() SyncOption {
+	return func(o *syncOptions) {
+		o.deleteRemoved = true
+	}
+}
+
+func newSyncOptions(opts []SyncOption) syncOptions {
+	var o syncOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// SelectOption configures SelectObjectContent.
+type SelectOption func(*selectOptions)
+
+type selectOptions struct {
+	// jsonType is the type of JSON input, CSV is queried if empty.
+	jsonType       types.JSONType
+	fileHeaderInfo types.FileHeaderInfo
+	compression    types.CompressionType
+	stats          func(SelectStats)
+}
+
+// SelectStats holds the statistics of a query reported by S3 at the end of SelectObjectContent.
+type SelectStats struct {
+	BytesScanned   int64
+	BytesProcessed int64
+	BytesReturned  int64
+}
+
+// WithCSVInput sets how the first line of CSV objects is used, e.g. types.FileHeaderInfoUse to refer to
+// the columns by name in the expression. CSV is queried by default, with the first line used as the header.
+func WithCSVInput(fileHeaderInfo types.FileHeaderInfo) SelectOption {
+	return func(o *selectOptions) {
+		o.jsonType = ""
+		o.fileHeaderInfo = fileHeaderInfo
+	}
+}
+
+// WithJSONInput queries JSON objects, either a single JSON document or JSON lines.
+// The selected records are returned as JSON lines.
+func WithJSONInput(jsonType types.JSONType) SelectOption {
+	return func(o *selectOptions) {
+		o.jsonType = jsonType
+	}
+}
+
+// WithSelectCompression sets the compression of the queried object, e.g. types.CompressionTypeGzip.
+func WithSelectCompression(compression types.CompressionType) SelectOption {
+	return func(o *selectOptions) {
+		o.compression = compression
+	}
+}
+
+// WithSelectStats calls fn with the statistics of the query once S3 reports them.
+func WithSelectStats(fn func(SelectStats)) SelectOption {
+	return func(o *selectOptions) {
+		o.stats = fn
+	}
+}
+
+func newSelectOptions(opts []SelectOption) (selectOptions, error) {
+	o := selectOptions{
+		fileHeaderInfo: types.FileHeaderInfoUse,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.jsonType != "" && !slices.Contains(o.jsonType.Values(), o.jsonType) {
+		return o, NewValidationError("unknown JSON type: " + string(o.jsonType))
+	}
+
+	if !slices.Contains(o.fileHeaderInfo.Values(), o.fileHeaderInfo) {
+		return o, NewValidationError("unknown file header info: " + string(o.fileHeaderInfo))
+	}
+
+	if o.compression != "" && !slices.Contains(o.compression.Values(), o.compression) {
+		return o, NewValidationError("unknown compression type: " + string(o.compression))
+	}
+
+	return o, nil
+}
@@ -0,0 +1,176 @@
+package s3utils
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const datePartitionPrefix = "_date="
+
+// KeyLayout describes the levels of the date partition in the keys of UploadFileWithDateDestination.
+// The year, month and day levels are always present and hold the label, the separator and the value,
+// or only the value if the label is empty; e.g. {YearLabel: "yr", Separator: "/"} yields "yr/2024".
+type KeyLayout struct {
+	YearLabel  string
+	MonthLabel string
+	DayLabel   string
+	// DateLabel is the label of the last level holding the whole date in the YYYY-MM-DD format.
+	// The level is omitted if DateLabel is empty.
+	DateLabel string
+	// Separator is placed between the label and the value of a level, e.g. "=" or "/".
+	Separator string
+}
+
+// DefaultKeyLayout returns the Hive-style layout of the date partitions,
+// e.g. "_year=2024/_month=09/_day=30/_date=2024-09-30".
+func DefaultKeyLayout() KeyLayout {
+	return KeyLayout{
+		YearLabel:  "_year",
+		MonthLabel: "_month",
+		DayLabel:   "_day",
+		DateLabel:  "_date",
+		Separator:  "=",
+	}
+}
+
+func (l KeyLayout) validate() error {
+	for _, label := range []string{l.YearLabel, l.MonthLabel, l.DayLabel, l.DateLabel} {
+		if strings.Contains(label, "/") {
+			return NewValidationError("key layout label must not contain a slash: " + label)
+		}
+	}
+
+	return nil
+}
+
+// partition returns the date partition of the date, without leading and trailing slashes.
+func (l KeyLayout) partition(date time.Time) string {
+	levels := []string{
+		l.level(l.YearLabel, date.Format("2006")),
+		l.level(l.MonthLabel, date.Format("01")),
+		l.level(l.DayLabel, date.Format("02")),
+	}
+
+	if l.DateLabel != "" {
+		levels = append(levels, l.level(l.DateLabel, date.Format(time.DateOnly)))
+	}
+
+	return strings.Join(levels, "/")
+}
+
+func (l KeyLayout) level(label string, value string) string {
+	if label == "" {
+		return value
+	}
+
+	return label + l.Separator + value
+}
+
+// datePattern returns a regular expression matching the date partition of the layout in a key,
+// with the year, month and day as submatches.
+func (l KeyLayout) datePattern() *regexp.Regexp {
+	levels := []string{
+		l.levelPattern(l.YearLabel, `(\d{4})`),
+		l.levelPattern(l.MonthLabel, `(\d{2})`),
+		l.levelPattern(l.DayLabel, `(\d{2})`),
+	}
+
+	if l.DateLabel != "" {
+		levels = append(levels, l.levelPattern(l.DateLabel, `\d{4}-\d{2}-\d{2}`))
+	}
+
+	return regexp.MustCompile(`(?:^|/)` + strings.Join(levels, "/") + `(?:/|$)`)
+}
+
+func (l KeyLayout) levelPattern(label string, value string) string {
+	if label == "" {
+		return value
+	}
+
+	return regexp.QuoteMeta(label+l.Separator) + value
+}
+
+// parseDate returns the date of the first date partition of the layout in the key.
+func parseDate(pattern *regexp.Regexp, key string) (time.Time, bool) {
+	match := pattern.FindStringSubmatch(key)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(time.DateOnly, match[1]+"-"+match[2]+"-"+match[3])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
+// UnpartitionedGroup is the ListByDatePartition group of objects whose key has no date partition.
+const UnpartitionedGroup = "unpartitioned"
+
+// ParseDateFromKey returns the date of the _date=YYYY-MM-DD partition in the key,
+// as written by UploadFileWithDateDestination. It returns false if the key has no valid date partition.
+func ParseDateFromKey(key string) (time.Time, bool) {
+	for _, segment := range strings.Split(key, "/") {
+		value, ok := strings.CutPrefix(segment, datePartitionPrefix)
+		if !ok {
+			continue
+		}
+
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return date, true
+	}
+
+	return time.Time{}, false
+}
+
+// ListByDatePartition lists the objects under the directory grouped by the date of their partition
+// in the YYYY-MM-DD format. Objects without a date partition are grouped under UnpartitionedGroup.
+// With WithKeyLayout the date is parsed from the partition of the layout instead of the _date= level.
+func (s *Client) ListByDatePartition(ctx context.Context, bucketName string, directory string) (map[string][]ObjectInfo, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	if directory == "" {
+		return nil, NewValidationError("directory is empty")
+	}
+
+	prefix := strings.Trim(directory, "/") + "/"
+	partitions := make(map[string][]ObjectInfo)
+
+	parse := ParseDateFromKey
+	if s.options.layout != nil {
+		pattern := s.options.layout.datePattern()
+
+		parse = func(key string) (time.Time, bool) {
+			return parseDate(pattern, key)
+		}
+	}
+
+	err := s.walkObjects(ctx, bucketName, prefix, func(object types.Object) error {
+		info := objectInfoFromObject(object)
+
+		group := UnpartitionedGroup
+		if date, ok := parse(info.Key); ok {
+			group = date.Format(time.DateOnly)
+		}
+
+		partitions[group] = append(partitions[group], info)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return partitions, nil
+}
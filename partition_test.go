@@ -0,0 +1,166 @@
+package s3utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDateFromKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "date_partition",
+			key:    "directory/_year=2024/_month=09/_day=30/_date=2024-09-30/test.json",
+			want:   time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "invalid_date",
+			key:  "directory/_date=2024-13-01/test.json",
+		},
+		{
+			name: "no_partition",
+			key:  "directory/test.json",
+		},
+		{
+			name: "date_in_filename",
+			key:  "directory/test_date=2024-09-30.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDateFromKey(tt.key)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("actual `%v, %v` \n expected `%v, %v`", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestClient_ListByDatePartition(t *testing.T) {
+	fake := newFakeS3()
+	fake.pageSize = 2
+	fake.put("bucket", "events/_year=2024/_month=09/_day=29/_date=2024-09-29/a.json", []byte("1"))
+	fake.put("bucket", "events/_year=2024/_month=09/_day=30/_date=2024-09-30/b.json", []byte("1"))
+	fake.put("bucket", "events/_year=2024/_month=09/_day=30/_date=2024-09-30/c.json", []byte("1"))
+	fake.put("bucket", "events/manual/d.json", []byte("1"))
+	fake.put("bucket", "other/_year=2024/_month=09/_day=30/_date=2024-09-30/e.json", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	partitions, err := client.ListByDatePartition(context.Background(), "bucket", "/events/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string][]string, len(partitions))
+	for group, objects := range partitions {
+		for _, object := range objects {
+			got[group] = append(got[group], object.Key)
+		}
+	}
+
+	want := map[string][]string{
+		"2024-09-29": {"events/_year=2024/_month=09/_day=29/_date=2024-09-29/a.json"},
+		"2024-09-30": {
+			"events/_year=2024/_month=09/_day=30/_date=2024-09-30/b.json",
+			"events/_year=2024/_month=09/_day=30/_date=2024-09-30/c.json",
+		},
+		UnpartitionedGroup: {"events/manual/d.json"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actual `%v` \n expected `%v`", got, want)
+	}
+}
+
+func TestKeyLayout_partition(t *testing.T) {
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		layout KeyLayout
+		want   string
+	}{
+		{
+			name:   "default",
+			layout: DefaultKeyLayout(),
+			want:   "_year=2024/_month=09/_day=30/_date=2024-09-30",
+		},
+		{
+			name:   "hive_without_date",
+			layout: KeyLayout{YearLabel: "year", MonthLabel: "month", DayLabel: "day", Separator: "="},
+			want:   "year=2024/month=09/day=30",
+		},
+		{
+			name:   "slash_separator",
+			layout: KeyLayout{YearLabel: "yr", MonthLabel: "mo", DayLabel: "dy", Separator: "/"},
+			want:   "yr/2024/mo/09/dy/30",
+		},
+		{
+			name:   "values_only",
+			layout: KeyLayout{},
+			want:   "2024/09/30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.layout.partition(date); got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+
+			got, ok := parseDate(tt.layout.datePattern(), "events/"+tt.want+"/data.json")
+			if !ok || !got.Equal(date) {
+				t.Errorf("actual `%v %v` \n expected `%v true`", got, ok, date)
+			}
+		})
+	}
+}
+
+func TestClient_WithKeyLayout(t *testing.T) {
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+	layout := KeyLayout{YearLabel: "yr", MonthLabel: "mo", DayLabel: "dy", Separator: "/"}
+
+	fake := newFakeS3()
+	fake.put("bucket", "events/manual/d.json", []byte("1"))
+
+	client := newTestClient(t, fake, WithKeyLayout(layout))
+
+	info, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "events", writeTestFile(t, "a.json", "{}"), date, WithPartitionMarker(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "events/yr/2024/mo/09/dy/30/a.json"; info.Key != want || client.BuildDateKey("events", "a.json", date) != want {
+		t.Errorf("actual `%v` \n expected `%v`", info.Key, want)
+	}
+
+	partitions, err := client.ListByDatePartition(context.Background(), "bucket", "events")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(partitions["2024-09-30"]) != 2 || len(partitions[UnpartitionedGroup]) != 1 {
+		t.Errorf("actual `%v` \n expected 2 objects in 2024-09-30 and 1 unpartitioned", partitions)
+	}
+
+	if err := client.DeleteFolderByDate(context.Background(), "bucket", "events", date); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := fake.get("bucket", info.Key); ok {
+		t.Errorf("object `%v` was not deleted", info.Key)
+	}
+
+	if _, err := NewClientWithAPI(fake, "us-east-1", WithKeyLayout(KeyLayout{YearLabel: "y/r"})); err == nil {
+		t.Error("expected error for a label with a slash")
+	}
+}
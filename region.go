@@ -0,0 +1,124 @@
+package s3utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type operationRegionKey struct{}
+
+// WithOperationRegion returns a copy of ctx that routes the operations called with it through a client for the
+// region, e.g. to access a bucket located in a region other than the client's. The region clients are created on
+// first use and cached by the client. The operations fail with a ValidationError if the client was created by
+// NewClientWithAPI on top of an S3API other than *s3.Client, which can't be copied for another region.
+func WithOperationRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, operationRegionKey{}, region)
+}
+
+func operationRegion(ctx context.Context) string {
+	region, _ := ctx.Value(operationRegionKey{}).(string)
+
+	return region
+}
+
+// regionClients caches the S3 API clients of the regions other than the client's default one.
+type regionClients struct {
+	api S3API
+	// region is the region of api.
+	region string
+	// newAPI creates the API client of a region. A nil newAPI makes region overrides fail.
+	newAPI func(region string) S3API
+
+	mu      sync.Mutex
+	clients map[string]S3API
+	// bucketRegions caches the regions of buckets detected with WithBucketRegionDetection.
+	bucketRegions map[string]bucketRegion
+}
+
+// failedBucketRegionTTL is how long a failed bucket region lookup is cached.
+const failedBucketRegionTTL = time.Minute
+
+// bucketRegion is the cached outcome of a bucket region lookup.
+type bucketRegion struct {
+	region string
+	err    error
+	// failedAt is the time of the lookup, used to expire failed lookups.
+	failedAt time.Time
+}
+
+// get returns the API client of the region, or the default one if the region is empty.
+// It returns a ValidationError if the region differs from the default one and can't be overridden.
+func (r *regionClients) get(region string) (S3API, error) {
+	if region == "" || region == r.region {
+		return r.api, nil
+	}
+
+	if r.newAPI == nil {
+		return nil, NewValidationError(fmt.Sprintf("region %s can't be used with an API client other than *s3.Client", region))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	api, ok := r.clients[region]
+	if !ok {
+		api = r.newAPI(region)
+
+		if r.clients == nil {
+			r.clients = make(map[string]S3API)
+		}
+
+		r.clients[region] = api
+	}
+
+	return api, nil
+}
+
+// newRegionAPIFunc returns a function creating copies of api for other regions.
+// It returns nil if api is not an *s3.Client, as other implementations can't be copied.
+func newRegionAPIFunc(api S3API) func(region string) S3API {
+	client, ok := api.(*s3.Client)
+	if !ok {
+		return nil
+	}
+
+	return func(region string) S3API {
+		return s3.New(client.Options(), func(o *s3.Options) {
+			o.Region = region
+		})
+	}
+}
+
+// bucketRegion returns the cached region of the bucket, looking it up with lookup on first use.
+// Failed lookups are cached for failedBucketRegionTTL, so that the operations on a bucket whose region
+// can't be looked up don't each send another lookup, while transient failures are retried later.
+func (r *regionClients) bucketRegion(bucketName string, lookup func() (string, error)) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.bucketRegions[bucketName]
+	r.mu.Unlock()
+
+	if ok && (cached.err == nil || time.Since(cached.failedAt) < failedBucketRegionTTL) {
+		return cached.region, cached.err
+	}
+
+	region, err := lookup()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.bucketRegions == nil {
+		r.bucketRegions = make(map[string]bucketRegion)
+	}
+
+	r.bucketRegions[bucketName] = bucketRegion{
+		region:   region,
+		err:      err,
+		failedAt: time.Now(),
+	}
+
+	return region, err
+}
@@ -0,0 +1,161 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestWithOperationRegion(t *testing.T) {
+	defaultAPI := newFakeS3()
+	regionAPI := newFakeS3()
+	regionAPI.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	var createdRegions []string
+
+	o, err := newClientOptions(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newClient(defaultAPI, "us-east-1", func(region string) S3API {
+		createdRegions = append(createdRegions, region)
+
+		return regionAPI
+	}, o)
+
+	ctx := WithOperationRegion(context.Background(), "eu-west-1")
+
+	for range 2 {
+		body, err := client.GetObjectBytes(ctx, "bucket", "raw/data.json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(body) != `{"a":1}` {
+			t.Errorf("actual `%s` \n expected `%s`", body, `{"a":1}`)
+		}
+	}
+
+	if len(createdRegions) != 1 || createdRegions[0] != "eu-west-1" {
+		t.Errorf("actual `%v` \n expected `%v`", createdRegions, []string{"eu-west-1"})
+	}
+
+	// Operations without the override use the default client.
+	_, err = client.GetObjectBytes(context.Background(), "bucket", "raw/data.json")
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestWithOperationRegion_unsupported(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake)
+
+	_, err := client.GetObjectBytes(WithOperationRegion(context.Background(), "eu-west-1"), "bucket", "raw/data.json")
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	// The default region of the client doesn't need another API client.
+	if _, err := client.GetObjectBytes(WithOperationRegion(context.Background(), "us-east-1"), "bucket", "raw/data.json"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_newRegionAPIFunc(t *testing.T) {
+	newAPI := newRegionAPIFunc(s3.New(s3.Options{Region: "us-east-1"}))
+	if newAPI == nil {
+		t.Fatal("expected region API func")
+	}
+
+	regionClient, ok := newAPI("eu-west-1").(*s3.Client)
+	if !ok {
+		t.Fatal("expected *s3.Client")
+	}
+
+	if region := regionClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("actual `%s` \n expected `%s`", region, "eu-west-1")
+	}
+
+	if newRegionAPIFunc(newFakeS3()) != nil {
+		t.Error("expected nil region API func for a non-SDK API")
+	}
+}
+
+// locationCountingS3 counts the GetBucketLocation calls.
+type locationCountingS3 struct {
+	*fakeS3
+	locationCalls int
+}
+
+func (f *locationCountingS3) GetBucketLocation(ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLocationOutput, error) {
+	f.locationCalls++
+
+	return f.fakeS3.GetBucketLocation(ctx, params, optFns...)
+}
+
+func TestWithBucketRegionDetection(t *testing.T) {
+	defaultAPI := &locationCountingS3{fakeS3: newFakeS3()}
+	defaultAPI.put("bucket", "raw/data.json", []byte(`{}`))
+	defaultAPI.bucketLocations = map[string]types.BucketLocationConstraint{"bucket": types.BucketLocationConstraintEuWest1}
+
+	regionAPI := newFakeS3()
+	regionAPI.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	var createdRegions []string
+
+	o, err := newClientOptions([]Option{WithBucketRegionDetection()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newClient(defaultAPI, "us-east-1", func(region string) S3API {
+		createdRegions = append(createdRegions, region)
+
+		return regionAPI
+	}, o)
+
+	for range 2 {
+		body, err := client.GetObjectBytes(context.Background(), "bucket", "raw/data.json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(body) != `{"a":1}` {
+			t.Errorf("actual `%s` \n expected `%s`", body, `{"a":1}`)
+		}
+	}
+
+	if len(createdRegions) != 1 || createdRegions[0] != "eu-west-1" {
+		t.Errorf("actual `%v` \n expected `%v`", createdRegions, []string{"eu-west-1"})
+	}
+
+	// A failed lookup falls back to the default client, which reports the error, and is not repeated.
+	for range 2 {
+		_, err = client.GetObjectBytes(context.Background(), "missing", "raw/data.json")
+		if err == nil {
+			t.Error("expected error")
+		}
+	}
+
+	if defaultAPI.locationCalls != 2 {
+		t.Errorf("actual `%v` lookups \n expected `2`", defaultAPI.locationCalls)
+	}
+}
+
+func TestWithBucketRegionDetection_unsupported(t *testing.T) {
+	_, err := NewClientWithAPI(newFakeS3(), "us-east-1", WithBucketRegionDetection())
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
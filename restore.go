@@ -0,0 +1,92 @@
+package s3utils
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// RestoreStatus describes the restore of an archived object, e.g. in the Glacier storage classes.
+type RestoreStatus struct {
+	// InProgress is set while the object is being restored.
+	InProgress bool `json:"in_progress"`
+	// ExpiryDate is the time when the restored copy is removed. It is zero while the restore is in progress.
+	ExpiryDate time.Time `json:"expiry_date,omitempty"`
+}
+
+// RestoreObject starts the restore of an archived object, making a temporary copy readable for the number of days.
+// The tier is one of Standard, Bulk or Expedited. The restore is asynchronous;
+// its progress is reported by the Restore field of the HeadObject result.
+func (s *Client) RestoreObject(ctx context.Context, bucketName string, key string, days int, tier string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if days <= 0 {
+		return NewValidationError("days must be positive")
+	}
+
+	if !slices.Contains(types.Tier("").Values(), types.Tier(tier)) {
+		return NewValidationError("unknown restore tier: " + tier)
+	}
+
+	key = strings.Trim(key, "/")
+
+	_, err := s.client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		RestoreRequest: &types.RestoreRequest{
+			Days: aws.Int32(int32(days)),
+			GlacierJobParameters: &types.GlacierJobParameters{
+				Tier: types.Tier(tier),
+			},
+		},
+	})
+	if err != nil {
+		return NewS3Error("unable to restore object", err)
+	}
+
+	return nil
+}
+
+// parseRestore parses the x-amz-restore header, e.g.
+// `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`.
+// It returns nil if the header is empty, i.e. no restore was requested.
+func parseRestore(header string) *RestoreStatus {
+	if header == "" {
+		return nil
+	}
+
+	var status RestoreStatus
+
+	for _, field := range strings.Split(header, `",`) {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+
+		value = strings.Trim(value, `"`)
+
+		switch name {
+		case "ongoing-request":
+			status.InProgress = value == "true"
+		case "expiry-date":
+			expiryDate, err := http.ParseTime(value)
+			if err == nil {
+				status.ExpiryDate = expiryDate
+			}
+		}
+	}
+
+	return &status
+}
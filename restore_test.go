@@ -0,0 +1,99 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_RestoreObject(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "archive/data.json", []byte("1"))
+
+	client := newTestClient(t, fake)
+
+	info, err := client.HeadObject(context.Background(), "bucket", "archive/data.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Restore != nil {
+		t.Errorf("actual `%v` \n expected no restore status", info.Restore)
+	}
+
+	err = client.RestoreObject(context.Background(), "bucket", "archive/data.json", 7, "Bulk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err = client.HeadObject(context.Background(), "bucket", "archive/data.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Restore == nil || !info.Restore.InProgress {
+		t.Errorf("actual `%v` \n expected a restore in progress", info.Restore)
+	}
+
+	tests := []struct {
+		name string
+		days int
+		tier string
+	}{
+		{
+			name: "zero_days",
+			days: 0,
+			tier: "Standard",
+		},
+		{
+			name: "unknown_tier",
+			days: 1,
+			tier: "Fast",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr ValidationError
+
+			err := client.RestoreObject(context.Background(), "bucket", "archive/data.json", tt.days, tt.tier)
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+func Test_parseRestore(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   *RestoreStatus
+	}{
+		{
+			name:   "not_requested",
+			header: "",
+			want:   nil,
+		},
+		{
+			name:   "in_progress",
+			header: `ongoing-request="true"`,
+			want:   &RestoreStatus{InProgress: true},
+		},
+		{
+			name:   "restored",
+			header: `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`,
+			want:   &RestoreStatus{ExpiryDate: time.Date(2012, 12, 21, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRestore(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
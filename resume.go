@@ -0,0 +1,190 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// manifestEntry is a line of the local manifest of UploadWithManifest, recording a completed upload.
+type manifestEntry struct {
+	Key  string `json:"key"`
+	ETag string `json:"etag"`
+	Size int64  `json:"size"`
+}
+
+// UploadWithManifest uploads files like UploadFiles and records every completed upload in the local manifest file,
+// so that an interrupted run can be resumed by calling it again with the same jobs and manifest.
+// Jobs recorded in the manifest are skipped, with Skipped set in their result, if HeadObject confirms
+// that their object still exists with the recorded ETag and size; otherwise they are uploaded again.
+// Local files are not compared with the uploaded objects, so changed files must be removed from the manifest.
+// The manifest is created if it doesn't exist; an incomplete last line left by an interruption is discarded.
+// A resumed run must use the same options as the interrupted one: WithGzip and PreserveSlashes change the keys,
+// and WithAtomicUpload the ETags of objects larger than 5 GiB, so changing them uploads the files again.
+func (s *Client) UploadWithManifest(ctx context.Context, bucketName string, jobs []UploadJob, manifestPath string, concurrency int, opts ...UploadOption) ([]UploadResult, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return nil, err
+	}
+
+	if manifestPath == "" {
+		return nil, NewValidationError("manifest path is empty")
+	}
+
+	if concurrency < 1 {
+		return nil, NewValidationError("concurrency must be positive")
+	}
+
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := readUploadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := os.OpenFile(manifestPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, NewSDKError("unable to open manifest", err)
+	}
+
+	defer manifest.Close()
+
+	ctx = s.startRetryBudget(ctx)
+
+	results := make([]UploadResult, len(jobs))
+	for i, job := range jobs {
+		results[i].Job = job
+	}
+
+	var mu sync.Mutex
+
+	dispatched := runPool(ctx, len(jobs), concurrency, func(i int) {
+		job := jobs[i]
+
+		if entry, ok := completed[uploadJobKey(job, o)]; ok {
+			uploaded, err := s.isUploaded(ctx, bucketName, entry)
+			if err != nil {
+				results[i].Err = err
+
+				return
+			}
+
+			if uploaded {
+				results[i].Info = UploadInfo{Key: entry.Key, ETag: entry.ETag, Size: entry.Size}
+				results[i].Skipped = true
+
+				return
+			}
+		}
+
+		info, err := s.UploadFileBase(ctx, bucketName, job.Directory, job.FilePath, job.ExternalFilename, opts...)
+		if err != nil {
+			results[i].Err = err
+
+			return
+		}
+
+		results[i].Info = info
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		results[i].Err = writeManifestEntry(manifest, manifestEntry{Key: info.Key, ETag: info.ETag, Size: info.Size})
+	})
+
+	if err := ctx.Err(); err != nil {
+		for i := dispatched; i < len(jobs); i++ {
+			results[i].Err = err
+		}
+
+		return results, err
+	}
+
+	return results, nil
+}
+
+// uploadJobKey returns the key of the object uploaded by UploadFileBase for the job.
+func uploadJobKey(job UploadJob, o uploadOptions) string {
+	return o.objectKey(job.Directory, job.ExternalFilename)
+}
+
+// isUploaded reports whether the object recorded in the manifest entry exists with the recorded ETag and size.
+func (s *Client) isUploaded(ctx context.Context, bucketName string, entry manifestEntry) (bool, error) {
+	headResp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(entry.Key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+
+		return false, NewS3Error("unable to get object info", err)
+	}
+
+	return aws.ToString(headResp.ETag) == entry.ETag && aws.ToInt64(headResp.ContentLength) == entry.Size, nil
+}
+
+// readUploadManifest returns the entries of the manifest by key, the last entry of a key winning.
+// A missing manifest has no entries. An incomplete last line is truncated, so that new entries start on a new line.
+func readUploadManifest(path string) (map[string]manifestEntry, error) {
+	entries := make(map[string]manifestEntry)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+
+	if err != nil {
+		return nil, NewSDKError("unable to read manifest", err)
+	}
+
+	complete := data[:bytes.LastIndexByte(data, '\n')+1]
+	if len(complete) < len(data) {
+		err = os.Truncate(path, int64(len(complete)))
+		if err != nil {
+			return nil, NewSDKError("unable to truncate manifest", err)
+		}
+	}
+
+	for _, line := range bytes.Split(complete, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry manifestEntry
+
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, NewSDKError("unable to decode manifest", err)
+		}
+
+		entries[entry.Key] = entry
+	}
+
+	return entries, nil
+}
+
+// writeManifestEntry appends the entry to the manifest as a JSON line.
+func writeManifestEntry(manifest *os.File, entry manifestEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return NewSDKError("unable to encode manifest entry", err)
+	}
+
+	_, err = manifest.Write(append(line, '\n'))
+	if err != nil {
+		return NewSDKError("unable to write manifest", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestClient_UploadWithManifest(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	jobs := make([]UploadJob, 0, 3)
+	for _, name := range []string{"a.json", "b.json", "c.json"} {
+		jobs = append(jobs, UploadJob{Directory: "raw", FilePath: writeTestFile(t, name, `{"a":1}`), ExternalFilename: name})
+	}
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+	// The first run uploads two files and is interrupted while recording a third one.
+	results, err := client.UploadWithManifest(context.Background(), "bucket", jobs[:2], manifestPath, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Err != nil || result.Skipped {
+			t.Errorf("job %v: actual `%v %v` \n expected upload", result.Job.ExternalFilename, result.Err, result.Skipped)
+		}
+	}
+
+	manifest, err := os.OpenFile(manifestPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := manifest.WriteString(`{"key":"raw/c.js`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	manifest.Close()
+
+	// An object removed since the first run is uploaded again.
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/b.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fake.putInputs = nil
+
+	results, err = client.UploadWithManifest(context.Background(), "bucket", jobs, manifestPath, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSkipped := []bool{true, false, false}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Errorf("job %v: unexpected error: %v", result.Job.ExternalFilename, result.Err)
+		}
+
+		if result.Skipped != wantSkipped[i] {
+			t.Errorf("job %v: actual skipped `%v` \n expected `%v`", result.Job.ExternalFilename, result.Skipped, wantSkipped[i])
+		}
+
+		if want := "raw/" + result.Job.ExternalFilename; result.Info.Key != want {
+			t.Errorf("actual `%v` \n expected `%v`", result.Info.Key, want)
+		}
+	}
+
+	uploaded := make([]string, 0, len(fake.putInputs))
+	for _, input := range fake.putInputs {
+		uploaded = append(uploaded, aws.ToString(input.Key))
+	}
+
+	if len(uploaded) != 2 || strings.Contains(strings.Join(uploaded, ","), "raw/a.json") {
+		t.Errorf("actual uploads `%v` \n expected `raw/b.json` and `raw/c.json`", uploaded)
+	}
+
+	entries, err := readUploadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"raw/a.json", "raw/b.json", "raw/c.json"} {
+		object, _ := fake.get("bucket", key)
+		if entry := entries[key]; entry.ETag != object.etag || entry.Size != int64(len(object.body)) {
+			t.Errorf("actual entry `%v` \n expected etag `%v` and size `%v`", entry, object.etag, len(object.body))
+		}
+	}
+}
+
+func TestClient_UploadWithManifest_invalidManifest(t *testing.T) {
+	client := newTestClient(t, newFakeS3())
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.jsonl")
+	if err := os.WriteFile(manifestPath, []byte("raw/a.json\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobs := []UploadJob{{Directory: "raw", FilePath: writeTestFile(t, "a.json", `{"a":1}`), ExternalFilename: "a.json"}}
+
+	_, err := client.UploadWithManifest(context.Background(), "bucket", jobs, manifestPath, 1)
+
+	var sdkErr SDKError
+	if !errors.As(err, &sdkErr) {
+		t.Errorf("actual error `%v` \n expected SDKError", err)
+	}
+}
@@ -0,0 +1,133 @@
+package s3utils
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// newRetryer creates a standard SDK retryer with an exponential full-jitter backoff starting at baseDelay
+// and capped at maxBackoff. Zero maxAttempts, baseDelay and maxBackoff keep the SDK defaults.
+// Retries consume the retry budget of the request context.
+func newRetryer(maxAttempts int, baseDelay time.Duration, maxBackoff time.Duration) aws.Retryer {
+	var retryer aws.Retryer = retry.NewStandard(func(o *retry.StandardOptions) {
+		if maxAttempts > 0 {
+			o.MaxAttempts = maxAttempts
+		}
+
+		if baseDelay > 0 {
+			maxDelay := max(baseDelay, retry.DefaultMaxBackoff)
+			if maxBackoff > 0 {
+				maxDelay = maxBackoff
+			}
+
+			o.Backoff = jitterBackoff{
+				baseDelay: baseDelay,
+				maxDelay:  maxDelay,
+			}
+		}
+
+		o.RateLimiter = budgetRateLimiter{RateLimiter: o.RateLimiter}
+	})
+
+	if maxBackoff > 0 && baseDelay == 0 {
+		// Without a base delay the SDK backoff is kept and only its cap is replaced.
+		retryer = retry.AddWithMaxBackoffDelay(retryer, maxBackoff)
+	}
+
+	return retryer
+}
+
+type retryBudgetKey struct{}
+
+// retryBudget is the number of retries left to the requests sharing a context.
+type retryBudget struct {
+	remaining atomic.Int64
+}
+
+// withRetryBudget returns a copy of ctx whose requests share a budget of n retries.
+func withRetryBudget(ctx context.Context, n int) context.Context {
+	budget := &retryBudget{}
+	budget.remaining.Store(int64(n))
+
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// retryBudgetExhausted consumes a retry of the budget of the request context and reports whether it was consumed already.
+func retryBudgetExhausted(ctx context.Context) bool {
+	budget, ok := ctx.Value(retryBudgetKey{}).(*retryBudget)
+
+	return ok && budget.remaining.Add(-1) < 0
+}
+
+// budgetRateLimiter denies retries once the retry budget of the request context is consumed.
+// Requests without a budget are limited by the wrapped rate limiter only.
+type budgetRateLimiter struct {
+	retry.RateLimiter
+}
+
+func (l budgetRateLimiter) GetToken(ctx context.Context, cost uint) (func() error, error) {
+	if retryBudgetExhausted(ctx) {
+		return nil, ErrRetryBudgetExhausted
+	}
+
+	return l.RateLimiter.GetToken(ctx, cost)
+}
+
+// budgetRetryer denies the retries of the wrapped retryer once the retry budget of the request context
+// is consumed, for retryers that are not created by newRetryer.
+type budgetRetryer struct {
+	aws.Retryer
+}
+
+func (r budgetRetryer) GetRetryToken(ctx context.Context, opErr error) (func(error) error, error) {
+	if retryBudgetExhausted(ctx) {
+		return nil, ErrRetryBudgetExhausted
+	}
+
+	return r.Retryer.GetRetryToken(ctx, opErr)
+}
+
+func (r budgetRetryer) GetAttemptToken(ctx context.Context) (func(error) error, error) {
+	if retryer, ok := r.Retryer.(aws.RetryerV2); ok {
+		return retryer.GetAttemptToken(ctx)
+	}
+
+	return r.GetInitialToken(), nil
+}
+
+// startRetryBudget returns a copy of ctx with a new retry budget if WithRetryBudget is set
+// and ctx doesn't carry a budget already, so nested calls share the budget of the outermost one.
+func (s *Client) startRetryBudget(ctx context.Context) context.Context {
+	if s.options.retryBudget == 0 {
+		return ctx
+	}
+
+	if _, ok := ctx.Value(retryBudgetKey{}).(*retryBudget); ok {
+		return ctx
+	}
+
+	return withRetryBudget(ctx, s.options.retryBudget)
+}
+
+// jitterBackoff implements retry.BackoffDelayer. The delay before attempt n is a random duration
+// in [0, min(maxDelay, baseDelay*2^n)].
+type jitterBackoff struct {
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func (b jitterBackoff) BackoffDelay(attempt int, _ error) (time.Duration, error) {
+	delay := b.baseDelay
+	for i := 0; i < attempt && delay < b.maxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, b.maxDelay)
+
+	return time.Duration(rand.Int64N(int64(delay) + 1)), nil
+}
@@ -0,0 +1,312 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeHTTPClient responds with the queued responses in order and records the received requests.
+type fakeHTTPClient struct {
+	mu        sync.Mutex
+	responses []fakeHTTPResponse
+	requests  []*http.Request
+}
+
+// RoundTrip makes the fake usable as the transport of an http.Client.
+func (c *fakeHTTPClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.Do(req)
+}
+
+type fakeHTTPResponse struct {
+	status int
+	body   string
+}
+
+func (c *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, req)
+
+	response := fakeHTTPResponse{status: http.StatusOK}
+	if len(c.responses) > 0 {
+		response, c.responses = c.responses[0], c.responses[1:]
+	}
+
+	return &http.Response{
+		StatusCode: response.status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(response.body)),
+		Request:    req,
+	}, nil
+}
+
+func newHTTPTestClient(t *testing.T, httpClient aws.HTTPClient, retryer aws.Retryer, opts ...Option) *Client {
+	t.Helper()
+
+	return newTestClient(t, s3.New(s3.Options{
+		Region:       "us-east-1",
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   httpClient,
+		Retryer:      retryer,
+		BaseEndpoint: aws.String("https://s3.test"),
+		UsePathStyle: true,
+	}), opts...)
+}
+
+const slowDownBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`
+
+func TestWithRetry_slowDown(t *testing.T) {
+	httpClient := &fakeHTTPClient{
+		responses: []fakeHTTPResponse{
+			{status: http.StatusServiceUnavailable, body: slowDownBody},
+			{status: http.StatusServiceUnavailable, body: slowDownBody},
+			{status: http.StatusNoContent},
+		},
+	}
+
+	o, err := newClientOptions([]Option{WithRetry(3, time.Millisecond)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newHTTPTestClient(t, httpClient, o.retryer())
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(httpClient.requests) != 3 {
+		t.Errorf("actual `%v` requests \n expected `3`", len(httpClient.requests))
+	}
+}
+
+func TestWithRetry_exhausted(t *testing.T) {
+	httpClient := &fakeHTTPClient{
+		responses: []fakeHTTPResponse{
+			{status: http.StatusServiceUnavailable, body: slowDownBody},
+			{status: http.StatusServiceUnavailable, body: slowDownBody},
+			{status: http.StatusNoContent},
+		},
+	}
+
+	o, err := newClientOptions([]Option{WithRetry(2, time.Millisecond)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newHTTPTestClient(t, httpClient, o.retryer())
+
+	if err := client.DeleteObject(context.Background(), "bucket", "raw/test.json"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if len(httpClient.requests) != 2 {
+		t.Errorf("actual `%v` requests \n expected `2`", len(httpClient.requests))
+	}
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	responses := make([]fakeHTTPResponse, 0, 15)
+	for range cap(responses) {
+		responses = append(responses, fakeHTTPResponse{status: http.StatusServiceUnavailable, body: slowDownBody})
+	}
+
+	httpClient := &fakeHTTPClient{responses: responses}
+
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	client, err := NewClient(context.Background(), "us-east-1",
+		WithHTTPClient(&http.Client{Transport: httpClient}),
+		WithCredentials(aws.AnonymousCredentials{}),
+		WithEndpoint("https://s3.test"),
+		WithPathStyle(),
+		WithRetry(5, time.Millisecond),
+		WithRetryBudget(2),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobs := make([]UploadJob, 0, 3)
+	for _, name := range []string{"a.json", "b.json", "c.json"} {
+		jobs = append(jobs, UploadJob{Directory: "raw", FilePath: writeTestFile(t, name, `{"a":1}`), ExternalFilename: name})
+	}
+
+	results, err := client.UploadFiles(context.Background(), "bucket", jobs, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, result := range results {
+		if result.Err == nil {
+			t.Errorf("job %v: expected error", result.Job.ExternalFilename)
+		}
+	}
+
+	// The first upload is retried until the budget is consumed, the other ones are not retried.
+	if len(httpClient.requests) != 5 {
+		t.Errorf("actual `%v` requests \n expected `5`", len(httpClient.requests))
+	}
+
+	if !errors.Is(results[2].Err, ErrRetryBudgetExhausted) {
+		t.Errorf("actual error `%v` \n expected ErrRetryBudgetExhausted", results[2].Err)
+	}
+}
+
+func TestWithRetryBudget_fromConfig(t *testing.T) {
+	responses := make([]fakeHTTPResponse, 0, 15)
+	for range cap(responses) {
+		responses = append(responses, fakeHTTPResponse{status: http.StatusServiceUnavailable, body: slowDownBody})
+	}
+
+	httpClient := &fakeHTTPClient{responses: responses}
+
+	cfg := aws.Config{
+		Region:       "us-east-1",
+		Credentials:  aws.AnonymousCredentials{},
+		HTTPClient:   httpClient,
+		BaseEndpoint: aws.String("https://s3.test"),
+		Retryer: func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				o.MaxAttempts = 5
+				o.Backoff = jitterBackoff{baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+			})
+		},
+	}
+
+	client, err := NewClientFromConfig(cfg, "", WithRetryBudget(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobs := make([]UploadJob, 0, 3)
+	for _, name := range []string{"a.json", "b.json", "c.json"} {
+		jobs = append(jobs, UploadJob{Directory: "raw", FilePath: writeTestFile(t, name, `{"a":1}`), ExternalFilename: name})
+	}
+
+	results, err := client.UploadFiles(context.Background(), "bucket", jobs, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first upload is retried until the budget is consumed, the other ones are not retried.
+	if len(httpClient.requests) != 5 {
+		t.Errorf("actual `%v` requests \n expected `5`", len(httpClient.requests))
+	}
+
+	if !errors.Is(results[2].Err, ErrRetryBudgetExhausted) {
+		t.Errorf("actual error `%v` \n expected ErrRetryBudgetExhausted", results[2].Err)
+	}
+}
+
+func Test_jitterBackoff(t *testing.T) {
+	backoff := jitterBackoff{
+		baseDelay: 100 * time.Millisecond,
+		maxDelay:  time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{attempt: 0, limit: 100 * time.Millisecond},
+		{attempt: 1, limit: 200 * time.Millisecond},
+		{attempt: 3, limit: 800 * time.Millisecond},
+		{attempt: 10, limit: time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 100 {
+			delay, err := backoff.BackoffDelay(tt.attempt, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if delay < 0 || delay > tt.limit {
+				t.Errorf("attempt %d: actual `%v` \n expected in [0, %v]", tt.attempt, delay, tt.limit)
+			}
+		}
+	}
+}
+
+func TestWithMaxBackoff(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		limit time.Duration
+	}{
+		{
+			name:  "base_delay",
+			opts:  []Option{WithBaseRetryDelay(time.Millisecond), WithMaxBackoff(4 * time.Millisecond)},
+			limit: 4 * time.Millisecond,
+		},
+		{
+			name:  "sdk_backoff",
+			opts:  []Option{WithMaxBackoff(3 * time.Millisecond)},
+			limit: 3 * time.Millisecond,
+		},
+		{
+			name:  "with_retry",
+			opts:  []Option{WithRetry(5, 2*time.Millisecond), WithMaxBackoff(5 * time.Millisecond)},
+			limit: 5 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_CA_BUNDLE", "")
+
+			client, err := NewClient(context.Background(), "us-east-1", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			retryer := client.UnderlyingClient().Options().Retryer
+
+			for attempt := range 20 {
+				delay, err := retryer.RetryDelay(attempt, errors.New("SlowDown"))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if delay < 0 || delay > tt.limit {
+					t.Errorf("attempt %d: actual `%v` \n expected in [0, %v]", attempt, delay, tt.limit)
+				}
+			}
+		})
+	}
+
+	invalid := [][]Option{
+		{WithBaseRetryDelay(-time.Second)},
+		{WithMaxBackoff(-time.Second)},
+		{WithBaseRetryDelay(time.Second), WithMaxBackoff(time.Millisecond)},
+	}
+
+	for _, opts := range invalid {
+		var validationErr ValidationError
+		if _, err := newClientOptions(opts); !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestWithRetryBudget_withAPI(t *testing.T) {
+	_, err := NewClientWithAPI(newFakeS3(), "us-east-1", WithRetryBudget(2))
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
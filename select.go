@@ -0,0 +1,119 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// SelectObjectContent runs the SQL expression against a CSV or JSON object with S3 Select and returns
+// the selected records as a stream, so only the matching rows are transferred. CSV objects are queried by default;
+// use WithJSONInput for JSON objects. CSV records are returned as CSV and JSON records as JSON lines.
+// The caller is responsible for closing the returned reader.
+func (s *Client) SelectObjectContent(ctx context.Context, bucketName string, key string, expression string, opts ...SelectOption) (io.ReadCloser, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return nil, NewValidationError("key is empty")
+	}
+
+	if strings.TrimSpace(expression) == "" {
+		return nil, NewValidationError("expression is empty")
+	}
+
+	o, err := newSelectOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	key = strings.Trim(key, "/")
+
+	input := &s3.SelectObjectContentInput{
+		Bucket:         aws.String(bucketName),
+		Key:            aws.String(key),
+		Expression:     aws.String(expression),
+		ExpressionType: types.ExpressionTypeSql,
+		InputSerialization: &types.InputSerialization{
+			CompressionType: o.compression,
+		},
+		OutputSerialization: &types.OutputSerialization{},
+	}
+
+	if o.jsonType != "" {
+		input.InputSerialization.JSON = &types.JSONInput{Type: o.jsonType}
+		input.OutputSerialization.JSON = &types.JSONOutput{}
+	} else {
+		input.InputSerialization.CSV = &types.CSVInput{FileHeaderInfo: o.fileHeaderInfo}
+		input.OutputSerialization.CSV = &types.CSVOutput{}
+	}
+
+	output, err := s.client.SelectObjectContent(ctx, input)
+	if err != nil {
+		return nil, newGetObjectError("unable to select object content", err)
+	}
+
+	stream := output.GetStream()
+	if stream == nil {
+		return nil, NewSDKError("unable to select object content", errors.New("response has no event stream"))
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		defer stream.Close()
+
+		pipeWriter.CloseWithError(readSelectEvents(stream.Events(), stream.Err, pipeWriter, o))
+	}()
+
+	return &selectReader{PipeReader: pipeReader, stream: stream}, nil
+}
+
+// readSelectEvents writes the payload of the records events to w until the end event.
+// The events channel is closed early if the stream fails, in which case streamErr returns the failure.
+func readSelectEvents(events <-chan types.SelectObjectContentEventStream, streamErr func() error, w io.Writer, o selectOptions) error {
+	for event := range events {
+		switch e := event.(type) {
+		case *types.SelectObjectContentEventStreamMemberRecords:
+			if _, err := w.Write(e.Value.Payload); err != nil {
+				return err
+			}
+		case *types.SelectObjectContentEventStreamMemberStats:
+			if o.stats != nil && e.Value.Details != nil {
+				o.stats(SelectStats{
+					BytesScanned:   aws.ToInt64(e.Value.Details.BytesScanned),
+					BytesProcessed: aws.ToInt64(e.Value.Details.BytesProcessed),
+					BytesReturned:  aws.ToInt64(e.Value.Details.BytesReturned),
+				})
+			}
+		case *types.SelectObjectContentEventStreamMemberEnd:
+			return nil
+		}
+	}
+
+	if err := streamErr(); err != nil {
+		return NewS3Error("unable to read selected records", err)
+	}
+
+	// S3 sends the end event only once all records are sent, so the records are incomplete without it.
+	return NewS3Error("unable to read selected records", errors.New("event stream ended without an end event"))
+}
+
+// selectReader closes the event stream together with the pipe of the selected records,
+// which stops the goroutine reading the events.
+type selectReader struct {
+	*io.PipeReader
+	stream *s3.SelectObjectContentEventStream
+}
+
+func (r *selectReader) Close() error {
+	r.PipeReader.Close()
+
+	return r.stream.Close()
+}
@@ -0,0 +1,140 @@
+package s3utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// encodeSelectEvents encodes the events of a SelectObjectContent response, each given as an event type and a payload.
+func encodeSelectEvents(t *testing.T, events ...[2]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	encoder := eventstream.NewEncoder()
+
+	for _, event := range events {
+		err := encoder.Encode(&buf, eventstream.Message{
+			Headers: eventstream.Headers{
+				{Name: ":message-type", Value: eventstream.StringValue("event")},
+				{Name: ":event-type", Value: eventstream.StringValue(event[0])},
+			},
+			Payload: []byte(event[1]),
+		})
+		if err != nil {
+			t.Fatalf("unable to encode event: %v", err)
+		}
+	}
+
+	return buf.String()
+}
+
+func TestClient_SelectObjectContent(t *testing.T) {
+	statsPayload := `<Stats><BytesScanned>100</BytesScanned><BytesProcessed>100</BytesProcessed><BytesReturned>12</BytesReturned></Stats>`
+
+	tests := []struct {
+		name      string
+		body      string
+		want      string
+		wantStats SelectStats
+		wantErr   bool
+	}{
+		{
+			name: "records",
+			body: encodeSelectEvents(t,
+				[2]string{"Records", "1,alpha\n"},
+				[2]string{"Cont", ""},
+				[2]string{"Records", "2,beta\n"},
+				[2]string{"Stats", statsPayload},
+				[2]string{"End", ""},
+			),
+			want:      "1,alpha\n2,beta\n",
+			wantStats: SelectStats{BytesScanned: 100, BytesProcessed: 100, BytesReturned: 12},
+		},
+		{
+			name: "missing_end",
+			body: encodeSelectEvents(t,
+				[2]string{"Records", "1,alpha\n"},
+			),
+			want:    "1,alpha\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{{status: http.StatusOK, body: tt.body}}}
+			client := newHTTPTestClient(t, httpClient, nil)
+
+			var stats SelectStats
+
+			reader, err := client.SelectObjectContent(context.Background(), "bucket", "raw/data.csv", "SELECT * FROM S3Object s WHERE s.id < 3",
+				WithSelectStats(func(s SelectStats) { stats = s }))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer reader.Close()
+
+			got, err := io.ReadAll(reader)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("actual error `%v` \n expected error: %v", err, tt.wantErr)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("actual `%s` \n expected `%s`", got, tt.want)
+			}
+
+			if stats != tt.wantStats {
+				t.Errorf("actual `%v` \n expected `%v`", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestClient_SelectObjectContent_validation(t *testing.T) {
+	client := newTestClient(t, newFakeS3())
+
+	tests := []struct {
+		name       string
+		expression string
+		opts       []SelectOption
+	}{
+		{
+			name:       "empty_expression",
+			expression: " ",
+		},
+		{
+			name:       "unknown_json_type",
+			expression: "SELECT * FROM S3Object",
+			opts:       []SelectOption{WithJSONInput("YAML")},
+		},
+		{
+			name:       "unknown_compression",
+			expression: "SELECT * FROM S3Object",
+			opts:       []SelectOption{WithSelectCompression("ZSTD")},
+		},
+		{
+			name:       "unknown_header_info",
+			expression: "SELECT * FROM S3Object",
+			opts:       []SelectOption{WithCSVInput(types.FileHeaderInfo("FIRST"))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.SelectObjectContent(context.Background(), "bucket", "raw/data.csv", tt.expression, tt.opts...)
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
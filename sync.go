@@ -0,0 +1,146 @@
+package s3utils
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// syncConcurrency is the number of files uploaded concurrently by SyncToBucket.
+const syncConcurrency = 10
+
+// syncFile is a local file found by SyncToBucket.
+type syncFile struct {
+	path string
+	rel  string
+	info fs.FileInfo
+}
+
+// SyncToBucket uploads the files of localDir, including subdirectories, under the prefix,
+// skipping the files whose object already has the same content. Files larger than 5 GiB are uploaded in parts.
+// Objects are compared by size and ETag; objects uploaded in parts have no plain MD5 ETag
+// and are compared by size and modification time instead.
+// With WithDeleteRemoved the objects under the prefix without a local file are deleted.
+// Failed uploads are joined into the returned error.
+func (s *Client) SyncToBucket(ctx context.Context, bucketName string, localDir string, prefix string, opts ...SyncOption) error {
+	if err := validateBucketName(bucketName); err != nil {
+		return err
+	}
+
+	if localDir == "" {
+		return NewValidationError("local directory is empty")
+	}
+
+	o := newSyncOptions(opts)
+
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	ctx = s.startRetryBudget(ctx)
+
+	remote, err := s.listRelative(ctx, bucketName, prefix)
+	if err != nil {
+		return err
+	}
+
+	var files []syncFile
+
+	local := make(map[string]bool)
+
+	err = filepath.WalkDir(localDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		local[rel] = true
+		files = append(files, syncFile{path: path, rel: rel, info: info})
+
+		return nil
+	})
+	if err != nil {
+		return NewSDKError("unable to read local directory", err)
+	}
+
+	errs := make([]error, len(files))
+
+	runPool(ctx, len(files), syncConcurrency, func(i int) {
+		object, ok := remote[files[i].rel]
+		errs[i] = s.syncFile(ctx, bucketName, prefix+files[i].rel, files[i], object, ok)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if !o.deleteRemoved {
+		return nil
+	}
+
+	var removed []string
+
+	for rel, object := range remote {
+		if !local[rel] {
+			removed = append(removed, object.Key)
+		}
+	}
+
+	_, err = s.deleteKeys(ctx, bucketName, removed, true)
+
+	return err
+}
+
+// syncFile uploads the local file to the key unless the existing object has the same content.
+func (s *Client) syncFile(ctx context.Context, bucketName string, key string, file syncFile, object ObjectInfo, exists bool) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return NewSDKError("unable to open file", err)
+	}
+
+	defer f.Close()
+
+	if exists && object.Size == file.info.Size() {
+		etag := strings.Trim(object.ETag, `"`)
+		if strings.Contains(etag, "-") {
+			if !file.info.ModTime().After(object.LastModified) {
+				return nil
+			}
+		} else {
+			sum, err := fileMD5(f)
+			if err != nil {
+				return err
+			}
+
+			if etag == hex.EncodeToString(sum) {
+				return nil
+			}
+		}
+	}
+
+	// Files up to 5 GiB are sent in a single request, larger ones are uploaded in parts.
+	_, err = s.putStream(ctx, bucketName, key, f, uploadOptions{contentLength: aws.Int64(file.info.Size())})
+
+	return err
+}
@@ -0,0 +1,103 @@
+package s3utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func writeSyncDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("unable to create test directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("unable to write test file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestClient_SyncToBucket(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []SyncOption
+		wantUploads []string
+		wantKeys    []string
+	}{
+		{
+			name:        "upload_changed",
+			wantUploads: []string{"backup/new.txt", "backup/sub/changed.txt"},
+			wantKeys:    []string{"backup/new.txt", "backup/stale.txt", "backup/sub/changed.txt", "backup/unchanged.txt"},
+		},
+		{
+			name:        "delete_removed",
+			opts:        []SyncOption{WithDeleteRemoved()},
+			wantUploads: []string{"backup/new.txt", "backup/sub/changed.txt"},
+			wantKeys:    []string{"backup/new.txt", "backup/sub/changed.txt", "backup/unchanged.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSyncDir(t, map[string]string{
+				"unchanged.txt":   "same",
+				"sub/changed.txt": "new content",
+				"new.txt":         "new",
+			})
+
+			fake := newFakeS3()
+			fake.put("bucket", "backup/unchanged.txt", []byte("same"))
+			fake.put("bucket", "backup/sub/changed.txt", []byte("old content"))
+			fake.put("bucket", "backup/stale.txt", []byte("stale"))
+			fake.put("bucket", "other/unchanged.txt", []byte("other"))
+
+			client := newTestClient(t, fake)
+
+			err := client.SyncToBucket(context.Background(), "bucket", dir, "/backup/", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var uploads []string
+			for _, input := range fake.putInputs {
+				uploads = append(uploads, aws.ToString(input.Key))
+			}
+
+			slices.Sort(uploads)
+
+			if !slices.Equal(uploads, tt.wantUploads) {
+				t.Errorf("actual uploads `%v` \n expected `%v`", uploads, tt.wantUploads)
+			}
+
+			var keys []string
+			for key := range fake.objects["bucket"] {
+				if key != "other/unchanged.txt" {
+					keys = append(keys, key)
+				}
+			}
+
+			slices.Sort(keys)
+
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("actual keys `%v` \n expected `%v`", keys, tt.wantKeys)
+			}
+
+			object, _ := fake.get("bucket", "backup/sub/changed.txt")
+			if string(object.body) != "new content" {
+				t.Errorf("actual content `%s` \n expected `new content`", object.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package s3utils
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	gzipEncoding  = "gzip"
+	gzipExtension = ".gz"
+	// maxPutObjectSize is the largest object that a single PutObject request can upload.
+	maxPutObjectSize = 5 * 1024 * 1024 * 1024
+)
+
+// UploadInfo describes an object uploaded by UploadFileBase, UploadFileWithDateDestination, UploadReader or UploadJSON.
+type UploadInfo struct {
+	// Key is the key of the uploaded object, including the date partition or the extension added by WithGzip.
+	Key  string
+	ETag string
+	// ChecksumAlgorithm and Checksum are the base64-encoded flexible checksum verified by S3,
+	// empty unless set with WithChecksumAlgorithm. The checksum of a multipart upload is computed
+	// from the checksums of its parts and suffixed with the number of parts.
+	ChecksumAlgorithm types.ChecksumAlgorithm
+	Checksum          string
+	// Size is the number of bytes sent, which is the compressed size with WithGzip.
+	Size int64
+	// Duration is the time spent sending the content, excluding backups and verifications,
+	// so that Size divided by Duration is the upload throughput.
+	Duration time.Duration
+}
+
+// UploadReader uploads the content of the reader to the directory under the filename.
+// The content is streamed, so readers of unknown length are uploaded in parts without buffering them whole:
+// the upload manager buffers parts of 5 MiB and switches to a multipart upload for bodies larger than a part.
+// With WithContentLength bodies up to 5 GiB are sent in a single PutObject request without buffering.
+func (s *Client) UploadReader(ctx context.Context, bucketName string, directory string, filename string, body io.Reader, opts ...UploadOption) (UploadInfo, error) {
+	if err := validateBucketName(bucketName); err != nil {
+		return UploadInfo{}, err
+	}
+
+	if directory == "" {
+		return UploadInfo{}, NewValidationError("directory is empty")
+	}
+
+	if filename == "" {
+		return UploadInfo{}, NewValidationError("filename is empty")
+	}
+
+	if body == nil {
+		return UploadInfo{}, NewValidationError("body is nil")
+	}
+
+	o, err := newUploadOptions(opts)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	if err := o.validateFilenameUpload("UploadReader"); err != nil {
+		return UploadInfo{}, err
+	}
+
+	return s.putStream(ctx, bucketName, o.objectKey(directory, filename), body, o)
+}
+
+// putStream uploads the body through the upload manager, which switches to a multipart upload for large bodies.
+// With WithGzip the body is compressed on the fly through a pipe.
+func (s *Client) putStream(ctx context.Context, bucketName string, objectKey string, body io.Reader, o uploadOptions) (UploadInfo, error) {
+	input := s.newPutObjectInput(bucketName, objectKey, o)
+
+	if o.gzip {
+		pipeReader, pipeWriter := io.Pipe()
+		// Unblocks the compressing goroutine if the upload stops reading early.
+		defer pipeReader.Close()
+
+		go func(src io.Reader) {
+			gzipWriter := gzip.NewWriter(pipeWriter)
+
+			_, err := io.Copy(gzipWriter, src)
+			if err == nil {
+				err = gzipWriter.Close()
+			}
+
+			pipeWriter.CloseWithError(err)
+		}(body)
+
+		body = pipeReader
+		input.ContentEncoding = aws.String(gzipEncoding)
+	}
+
+	if err := validateObjectKey(objectKey); err != nil {
+		return UploadInfo{}, err
+	}
+
+	// A seekable body of a known length is sent as is, so that the SDK can rewind it
+	// to compute the payload hash and to retry the request.
+	seeker, single := body.(io.ReadSeeker)
+	single = single && o.contentLength != nil && *o.contentLength <= maxPutObjectSize
+
+	if o.contentMD5 && !single {
+		return UploadInfo{}, NewValidationError("Content-MD5 is not supported by streamed uploads")
+	}
+
+	err := s.backupObject(ctx, bucketName, objectKey, o)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	if o.atomicUpload {
+		input.Key = aws.String(tempObjectKey(objectKey))
+	}
+
+	var sum []byte
+	if single && (o.contentMD5 || o.verifyETag) {
+		sum, err = fileMD5(seeker)
+		if err != nil {
+			return UploadInfo{}, err
+		}
+	}
+
+	if o.contentMD5 {
+		input.ContentMD5 = aws.String(base64.StdEncoding.EncodeToString(sum))
+	}
+
+	hash := md5.New()
+	if o.verifyETag && !single {
+		body = io.TeeReader(body, hash)
+	}
+
+	counter := &countingReader{r: body}
+
+	start := time.Now()
+
+	var uploadResp *manager.UploadOutput
+	if single {
+		input.Body = seeker
+		input.ContentLength = o.contentLength
+		uploadResp, err = s.putObject(ctx, input)
+	} else {
+		input.Body = counter
+		uploadResp, err = s.uploadManaged(ctx, input)
+	}
+
+	if err != nil {
+		return UploadInfo{}, newUploadError("unable to upload file", err, o)
+	}
+
+	duration := time.Since(start)
+
+	size := counter.n
+	if single {
+		size = *o.contentLength
+	} else {
+		sum = hash.Sum(nil)
+	}
+
+	uploadResp, err = s.completeUpload(ctx, bucketName, aws.ToString(input.Key), objectKey, size, uploadResp, sum, o)
+	if err != nil {
+		return UploadInfo{}, err
+	}
+
+	return UploadInfo{
+		Key:               objectKey,
+		ETag:              aws.ToString(uploadResp.ETag),
+		ChecksumAlgorithm: o.checksumAlgorithm,
+		Checksum:          checksumValue(o.checksumAlgorithm, uploadResp.ChecksumCRC32, uploadResp.ChecksumCRC32C, uploadResp.ChecksumSHA1, uploadResp.ChecksumSHA256),
+		Size:              size,
+		Duration:          duration,
+	}, nil
+}
+
+// checksumValue returns the checksum of the algorithm among the checksums of a response.
+func checksumValue(algorithm types.ChecksumAlgorithm, crc32 *string, crc32c *string, sha1 *string, sha256 *string) string {
+	switch algorithm {
+	case types.ChecksumAlgorithmCrc32:
+		return aws.ToString(crc32)
+	case types.ChecksumAlgorithmCrc32c:
+		return aws.ToString(crc32c)
+	case types.ChecksumAlgorithmSha1:
+		return aws.ToString(sha1)
+	case types.ChecksumAlgorithmSha256:
+		return aws.ToString(sha256)
+	}
+
+	return ""
+}
+
+// putObject uploads the input in a single PutObject request, reporting the response like the upload manager.
+func (s *Client) putObject(ctx context.Context, input *s3.PutObjectInput) (*manager.UploadOutput, error) {
+	putResp, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &manager.UploadOutput{
+		ETag:           putResp.ETag,
+		ChecksumCRC32:  putResp.ChecksumCRC32,
+		ChecksumCRC32C: putResp.ChecksumCRC32C,
+		ChecksumSHA1:   putResp.ChecksumSHA1,
+		ChecksumSHA256: putResp.ChecksumSHA256,
+		VersionID:      putResp.VersionId,
+	}, nil
+}
+
+// uploadManaged uploads the input through the upload manager. If a multipart upload fails,
+// also because ctx is cancelled, it is aborted so that its parts don't linger. The upload manager
+// aborts it with ctx itself, which fails once ctx is cancelled.
+func (s *Client) uploadManaged(ctx context.Context, input *s3.PutObjectInput) (*manager.UploadOutput, error) {
+	uploader := manager.NewUploader(s.client, func(u *manager.Uploader) {
+		u.LeavePartsOnError = true
+	})
+
+	uploadResp, err := uploader.Upload(ctx, input)
+
+	var multipartErr manager.MultiUploadFailure
+	if errors.As(err, &multipartErr) && multipartErr.UploadID() != "" {
+		s.abortMultipartUpload(ctx, aws.ToString(input.Bucket), aws.ToString(input.Key), aws.String(multipartErr.UploadID()))
+	}
+
+	return uploadResp, err
+}
+
+// completeUpload verifies the object uploaded to uploadKey as requested by the upload options.
+// For atomic uploads it then copies the object to objectKey and deletes the temporary object, also on failure.
+// The sum is the MD5 of the uploaded content, used only with WithETagVerification.
+// It returns the ETag and the checksums of the object at objectKey.
+func (s *Client) completeUpload(ctx context.Context, bucketName string, uploadKey string, objectKey string, size int64, uploaded *manager.UploadOutput, sum []byte, o uploadOptions) (*manager.UploadOutput, error) {
+	etag := aws.ToString(uploaded.ETag)
+
+	var err error
+	if o.verifyETag {
+		err = verifyETag(etag, sum)
+	}
+
+	if err == nil && o.verifyUpload {
+		err = s.verifyUpload(ctx, bucketName, uploadKey, size, etag)
+	}
+
+	if uploadKey == objectKey {
+		if err != nil {
+			return nil, err
+		}
+
+		return uploaded, nil
+	}
+
+	var copied *types.CopyObjectResult
+
+	if err == nil {
+		copyOpts := copyOptions{storageClass: o.storageClass, acl: o.acl, checksumAlgorithm: o.checksumAlgorithm}
+		if o.kmsKeyID != nil {
+			copyOpts.serverSideEncryption = types.ServerSideEncryptionAwsKms
+			copyOpts.kmsKeyID = o.kmsKeyID
+			copyOpts.encryptionContext = o.encodedEncryptionContext()
+		}
+
+		copied, err = s.copyObject(ctx, bucketName, uploadKey, bucketName, objectKey, size, copyOpts)
+	}
+
+	_, deleteErr := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(uploadKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deleteErr != nil {
+		return nil, NewS3Error("unable to delete temporary object", deleteErr)
+	}
+
+	return &manager.UploadOutput{
+		ETag:           copied.ETag,
+		ChecksumCRC32:  copied.ChecksumCRC32,
+		ChecksumCRC32C: copied.ChecksumCRC32C,
+		ChecksumSHA1:   copied.ChecksumSHA1,
+		ChecksumSHA256: copied.ChecksumSHA256,
+	}, nil
+}
+
+// validateFilenameUpload rejects the options of UploadFileWithDateDestination in the other uploads,
+// i.e. UploadFileBase, UploadReader, UploadJSON and the uploads to a given object key.
+func (o uploadOptions) validateFilenameUpload(method string) error {
+	if o.relativePath {
+		return NewValidationError("relative path is not supported by " + method)
+	}
+
+	if o.partitionMarker != "" {
+		return NewValidationError("partition marker is not supported by " + method)
+	}
+
+	return nil
+}
+
+// validateKeyUpload rejects the options that don't apply to the uploads to a given object key,
+// i.e. TransformObject and GenerateChecksumManifest.
+func (o uploadOptions) validateKeyUpload(method string) error {
+	if o.contentLength != nil {
+		return NewValidationError("content length is supported by UploadReader only")
+	}
+
+	if o.preserveSlashes {
+		return NewValidationError("preserving slashes is not supported by " + method)
+	}
+
+	return o.validateFilenameUpload(method)
+}
+
+// objectKey returns the key of the object uploaded to the directory under the filename by UploadFileBase,
+// UploadReader and UploadJSON. PreserveSlashes keeps the slashes around the directory and WithGzip appends
+// the ".gz" extension.
+func (o uploadOptions) objectKey(directory string, filename string) string {
+	key := generateObjectKeyBase(directory, filename)
+	if o.preserveSlashes {
+		key = generateObjectKeyPreserved(directory, filename)
+	}
+
+	if o.gzip {
+		key += gzipExtension
+	}
+
+	return key
+}
+
+// tempObjectKey returns a unique hidden key in the folder of the key for the atomic upload of the object.
+func tempObjectKey(key string) string {
+	dir, name := path.Split(key)
+
+	return fmt.Sprintf("%s.%s.%016x.tmp", dir, name, rand.Uint64())
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	return n, err
+}
+
+// newPutObjectInput creates the PutObject input with the fields configured by the client and upload options.
+func (s *Client) newPutObjectInput(bucketName string, objectKey string, o uploadOptions) *s3.PutObjectInput {
+	input := &s3.PutObjectInput{
+		Bucket:             aws.String(bucketName),
+		Key:                aws.String(objectKey),
+		StorageClass:       o.storageClass,
+		ACL:                o.acl,
+		IfMatch:            o.ifMatch,
+		IfNoneMatch:        o.ifNoneMatch,
+		CacheControl:       o.cacheControl,
+		ContentDisposition: o.contentDisposition,
+		Metadata:           o.metadata,
+	}
+
+	if o.kmsKeyID != nil {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = o.kmsKeyID
+		input.SSEKMSEncryptionContext = o.encodedEncryptionContext()
+	}
+
+	if len(o.tags) > 0 {
+		input.Tagging = aws.String(encodeTags(o.tags))
+	}
+
+	if o.objectLockMode != "" {
+		input.ObjectLockMode = o.objectLockMode
+		input.ObjectLockRetainUntilDate = aws.Time(o.objectLockRetainUntil)
+		// S3 requires an integrity checksum for uploads with object lock settings.
+		input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32
+	}
+
+	if o.checksumAlgorithm != "" {
+		input.ChecksumAlgorithm = o.checksumAlgorithm
+	}
+
+	contentType := o.contentType
+	if contentType == "" {
+		// The content type of a compressed object is the one of its content.
+		contentType = s.detectContentType(strings.TrimSuffix(objectKey, gzipExtension))
+	}
+
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	return input
+}
+
+// encodeTags encodes the tags as URL query parameters, as expected by the x-amz-tagging header.
+func encodeTags(tags map[string]string) string {
+	values := make(url.Values, len(tags))
+	for key, value := range tags {
+		values.Set(key, value)
+	}
+
+	// Spaces are percent-encoded instead of being encoded as plus signs.
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
+
+// encodedEncryptionContext returns the encryption context as sent to S3, nil if it is empty.
+func (o uploadOptions) encodedEncryptionContext() *string {
+	if len(o.encryptionContext) == 0 {
+		return nil
+	}
+
+	return aws.String(encodeEncryptionContext(o.encryptionContext))
+}
+
+// encodeEncryptionContext encodes the encryption context as base64-encoded JSON,
+// as expected by the x-amz-server-side-encryption-context header.
+func encodeEncryptionContext(encryptionContext map[string]string) string {
+	// Marshalling a map of strings can't fail.
+	body, _ := json.Marshal(encryptionContext)
+
+	return base64.StdEncoding.EncodeToString(body)
+}
+
+// detectContentType returns the content type for the extension of the key, looking it up first in the map set
+// with WithContentTypeMap and then in the standard MIME types. It returns an empty string for unknown extensions.
+func (s *Client) detectContentType(key string) string {
+	ext := strings.ToLower(path.Ext(key))
+	if ext == "" {
+		return ""
+	}
+
+	if contentType, ok := s.options.contentTypes[ext]; ok {
+		return contentType
+	}
+
+	return mime.TypeByExtension(ext)
+}
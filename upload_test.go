@@ -0,0 +1,1393 @@
+package s3utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func writeTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("unable to write test file: %v", err)
+	}
+
+	return path
+}
+
+func TestClient_UploadFileBase_preserveSlashes(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+
+	tests := []struct {
+		name    string
+		opts    []UploadOption
+		wantKey string
+	}{
+		{
+			name:    "default_trims",
+			wantKey: "raw/test/data.json",
+		},
+		{
+			name:    "preserve_slashes",
+			opts:    []UploadOption{PreserveSlashes()},
+			wantKey: "/raw/test/data.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			_, err := client.UploadFileBase(context.Background(), "bucket", "/raw/test/", filePath, "data.json", tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, ok := fake.get("bucket", tt.wantKey); !ok {
+				t.Errorf("object `%v` was not uploaded", tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestClient_UploadFileBase_backupOnOverwrite(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	first := writeTestFile(t, "first.json", `{"version":1}`)
+	second := writeTestFile(t, "second.json", `{"version":2}`)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", first, "data.json", WithBackupOnOverwrite(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := fake.get("bucket", "raw/data.json.bak"); ok {
+		t.Fatal("backup must not be created for a new object")
+	}
+
+	_, err = client.UploadFileBase(context.Background(), "bucket", "raw", second, "data.json", WithBackupOnOverwrite(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	backup, ok := fake.get("bucket", "raw/data.json.bak")
+	if !ok {
+		t.Fatal("backup was not created")
+	}
+
+	if string(backup.body) != `{"version":1}` {
+		t.Errorf("actual backup `%s` \n expected `%s`", backup.body, `{"version":1}`)
+	}
+
+	current, _ := fake.get("bucket", "raw/data.json")
+	if string(current.body) != `{"version":2}` {
+		t.Errorf("actual object `%s` \n expected `%s`", current.body, `{"version":2}`)
+	}
+}
+
+func TestClient_UploadFileWithDateDestination_storageClass(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date, WithStorageClass(types.StorageClassGlacier))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.putInputs) != 1 || fake.putInputs[0].StorageClass != types.StorageClassGlacier {
+		t.Errorf("actual `%v` \n expected a single upload with storage class `%v`", fake.putInputs, types.StorageClassGlacier)
+	}
+
+	_, err = client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date, WithStorageClass("COLD"))
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_UploadFileWithDateDestination_tags(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	tags := map[string]string{"retention": "90 days", "team": "data&ml", "tier": "hot=1"}
+
+	_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date, WithTags(tags))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "retention=90%20days&team=data%26ml&tier=hot%3D1"
+	if len(fake.putInputs) != 1 || aws.ToString(fake.putInputs[0].Tagging) != want {
+		t.Fatalf("actual `%v` \n expected a single upload with tagging `%v`", fake.putInputs, want)
+	}
+
+	_, err = client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fake.putInputs[1].Tagging != nil {
+		t.Errorf("actual `%v` \n expected no tagging", aws.ToString(fake.putInputs[1].Tagging))
+	}
+
+	invalid := []map[string]string{
+		{"": "value"},
+		{"key": strings.Repeat("v", 257)},
+		{"a": "", "b": "", "c": "", "d": "", "e": "", "f": "", "g": "", "h": "", "i": "", "j": "", "k": ""},
+	}
+
+	for _, tags := range invalid {
+		_, err = client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date, WithTags(tags))
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestClient_Upload_encryptionContext(t *testing.T) {
+	const keyID = "arn:aws:kms:us-east-1:123456789012:key/finance"
+
+	encryptionContext := map[string]string{"tenant": "acme", "department": "finance"}
+	want := base64.StdEncoding.EncodeToString([]byte(`{"department":"finance","tenant":"acme"}`))
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", writeTestFile(t, "test.json", `{"a":1}`), "test.json",
+		WithEncryptionContext(keyID, encryptionContext), WithAtomicUpload())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = client.UploadReader(context.Background(), "bucket", "raw", "stream.json", strings.NewReader(`{"a":1}`), WithEncryptionContext(keyID, encryptionContext))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.putInputs) != 2 {
+		t.Fatalf("actual %d uploads \n expected 2", len(fake.putInputs))
+	}
+
+	for _, input := range fake.putInputs {
+		if input.ServerSideEncryption != types.ServerSideEncryptionAwsKms || aws.ToString(input.SSEKMSKeyId) != keyID || aws.ToString(input.SSEKMSEncryptionContext) != want {
+			t.Errorf("actual `%v %v %v` \n expected `%v %v %v`", input.ServerSideEncryption, aws.ToString(input.SSEKMSKeyId), aws.ToString(input.SSEKMSEncryptionContext),
+				types.ServerSideEncryptionAwsKms, keyID, want)
+		}
+	}
+
+	// The copy of the atomic upload keeps the encryption settings.
+	if len(fake.copyInputs) != 1 || aws.ToString(fake.copyInputs[0].SSEKMSKeyId) != keyID || aws.ToString(fake.copyInputs[0].SSEKMSEncryptionContext) != want {
+		t.Errorf("actual copies `%v` \n expected one copy with key `%v` and encryption context `%v`", fake.copyInputs, keyID, want)
+	}
+
+	invalid := [][]UploadOption{
+		{WithEncryptionContext("", encryptionContext)},
+		{WithEncryptionContext(keyID, map[string]string{"": "acme"})},
+	}
+
+	for _, opts := range invalid {
+		_, err = client.UploadReader(context.Background(), "bucket", "raw", "stream.json", strings.NewReader(`{"a":1}`), opts...)
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestClient_Upload_encryptionContextHeaders(t *testing.T) {
+	const keyID = "arn:aws:kms:us-east-1:123456789012:key/finance"
+
+	httpClient := &fakeHTTPClient{}
+	client := newHTTPTestClient(t, httpClient, nil)
+
+	_, err := client.UploadReader(context.Background(), "bucket", "raw", "stream.json", strings.NewReader(`{"a":1}`),
+		WithEncryptionContext(keyID, map[string]string{"tenant": "acme"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(httpClient.requests) != 1 {
+		t.Fatalf("actual `%v` requests \n expected `1`", len(httpClient.requests))
+	}
+
+	header := httpClient.requests[0].Header
+	want := map[string]string{
+		"X-Amz-Server-Side-Encryption":                "aws:kms",
+		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": keyID,
+		"X-Amz-Server-Side-Encryption-Context":        base64.StdEncoding.EncodeToString([]byte(`{"tenant":"acme"}`)),
+	}
+
+	for name, value := range want {
+		if header.Get(name) != value {
+			t.Errorf("header %v: actual `%v` \n expected `%v`", name, header.Get(name), value)
+		}
+	}
+}
+
+func TestBuildKey(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	info, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "/raw/", filePath, date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if key := client.BuildDateKey("/raw/", filePath, date); key != info.Key {
+		t.Errorf("actual `%v` \n expected `%v`", key, info.Key)
+	}
+
+	info, err = client.UploadFileBase(context.Background(), "bucket", "/raw/", filePath, "data.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if key := BuildKey("/raw/", "data.json"); key != info.Key {
+		t.Errorf("actual `%v` \n expected `%v`", key, info.Key)
+	}
+}
+
+func TestClient_UploadReader_gzipRoundTrip(t *testing.T) {
+	content := strings.Repeat(`{"level":"info","msg":"request served"}`+"\n", 1000)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadReader(context.Background(), "bucket", "logs", "app.log", strings.NewReader(content), WithGzip())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, ok := fake.get("bucket", "logs/app.log.gz")
+	if !ok {
+		t.Fatal("object `logs/app.log.gz` was not uploaded")
+	}
+
+	if object.contentEncoding != "gzip" {
+		t.Errorf("actual content encoding `%v` \n expected `gzip`", object.contentEncoding)
+	}
+
+	if len(object.body) >= len(content) {
+		t.Errorf("actual size `%v` \n expected less than `%v`", len(object.body), len(content))
+	}
+
+	localPath := filepath.Join(t.TempDir(), "app.log")
+
+	err = client.GetObject(context.Background(), "bucket", "logs/app.log.gz", localPath, WithDecompress())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	downloaded, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(downloaded) != content {
+		t.Errorf("downloaded content doesn't match the uploaded one")
+	}
+
+	body, _, err := client.GetObjectWithHeaders(context.Background(), "bucket", "logs/app.log.gz", WithDecompress())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer body.Close()
+
+	streamed, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(streamed) != content {
+		t.Errorf("streamed content doesn't match the uploaded one")
+	}
+}
+
+func TestClient_UploadFileBase_gzip(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json", WithGzip())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, ok := fake.get("bucket", "raw/data.json.gz")
+	if !ok {
+		t.Fatal("object `raw/data.json.gz` was not uploaded")
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(object.body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(content) != `{"a":1}` {
+		t.Errorf("actual `%s` \n expected `%s`", content, `{"a":1}`)
+	}
+}
+
+func TestClient_UploadFileBase_contentType(t *testing.T) {
+	filePath := writeTestFile(t, "events.ndjson", `{"a":1}`+"\n")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		filename string
+		want     string
+	}{
+		{
+			name:     "custom_map",
+			opts:     []Option{WithContentTypeMap(map[string]string{".ndjson": "application/x-ndjson"})},
+			filename: "events.ndjson",
+			want:     "application/x-ndjson",
+		},
+		{
+			name:     "custom_map_without_dot",
+			opts:     []Option{WithContentTypeMap(map[string]string{"NDJSON": "application/x-ndjson"})},
+			filename: "events.NDJSON",
+			want:     "application/x-ndjson",
+		},
+		{
+			name:     "standard_mime",
+			filename: "events.json",
+			want:     "application/json",
+		},
+		{
+			name:     "unknown_extension",
+			filename: "events.unknown-ext",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake, tt.opts...)
+
+			_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, tt.filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := aws.ToString(fake.putInputs[0].ContentType); got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Upload_headers(t *testing.T) {
+	filePath := writeTestFile(t, "report.csv", "id\n1\n")
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	opts := []UploadOption{
+		WithCacheControl("max-age=3600"),
+		WithContentDisposition("attachment; filename=report.csv"),
+	}
+
+	if _, err := client.UploadFileBase(context.Background(), "bucket", "reports", filePath, "report.csv", opts...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.UploadReader(context.Background(), "bucket", "reports", "report.csv", strings.NewReader("id\n1\n"), opts...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, input := range fake.putInputs {
+		if got := aws.ToString(input.CacheControl); got != "max-age=3600" {
+			t.Errorf("actual `%v` \n expected `%v`", got, "max-age=3600")
+		}
+
+		if got := aws.ToString(input.ContentDisposition); got != "attachment; filename=report.csv" {
+			t.Errorf("actual `%v` \n expected `%v`", got, "attachment; filename=report.csv")
+		}
+	}
+
+	tests := []struct {
+		name string
+		opt  UploadOption
+	}{
+		{
+			name: "empty_cache_control",
+			opt:  WithCacheControl(""),
+		},
+		{
+			name: "empty_content_disposition",
+			opt:  WithContentDisposition(" "),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr ValidationError
+
+			_, err := client.UploadFileBase(context.Background(), "bucket", "reports", filePath, "report.csv", tt.opt)
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+		})
+	}
+}
+
+func TestClient_Upload_metadata(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{"a":1}`),
+		WithMetadata(map[string]string{"Source-System": "crm"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{"source-system": "crm"}
+
+	info, err := client.HeadObject(context.Background(), "bucket", "raw/data.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(info.Metadata, want) {
+		t.Errorf("actual `%v` \n expected `%v`", info.Metadata, want)
+	}
+
+	body, info, err := client.GetObjectWithHeaders(context.Background(), "bucket", "raw/data.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body.Close()
+
+	if !reflect.DeepEqual(info.Metadata, want) {
+		t.Errorf("actual `%v` \n expected `%v`", info.Metadata, want)
+	}
+
+	for _, key := range []string{"", "source system", "source:system", "источник"} {
+		var validationErr ValidationError
+
+		_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{"a":1}`),
+			WithMetadata(map[string]string{key: "crm"}))
+		if !errors.As(err, &validationErr) {
+			t.Errorf("key %q: actual error `%v` \n expected ValidationError", key, err)
+		}
+	}
+}
+
+func TestClient_UploadFileWithDateDestination_partitionMarker(t *testing.T) {
+	filePath := writeTestFile(t, "events.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		opt        UploadOption
+		wantMarker string
+	}{
+		{
+			name:       "default_name",
+			opt:        WithPartitionMarker(""),
+			wantMarker: "events/_year=2024/_month=09/_day=30/_date=2024-09-30/_SUCCESS",
+		},
+		{
+			name:       "custom_name",
+			opt:        WithPartitionMarker("_DONE"),
+			wantMarker: "events/_year=2024/_month=09/_day=30/_date=2024-09-30/_DONE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "events", filePath, date, tt.opt)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, ok := fake.get("bucket", "events/_year=2024/_month=09/_day=30/_date=2024-09-30/events.json"); !ok {
+				t.Error("data object was not uploaded")
+			}
+
+			marker, ok := fake.get("bucket", tt.wantMarker)
+			if !ok {
+				t.Fatal("marker object was not created")
+			}
+
+			if len(marker.body) != 0 {
+				t.Errorf("actual marker size `%v` \n expected `0`", len(marker.body))
+			}
+		})
+	}
+}
+
+func TestClient_UploadFileWithDateDestination_partitionMarkerOptions(t *testing.T) {
+	filePath := writeTestFile(t, "events.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "events", filePath, date,
+		WithPartitionMarker(""), WithStorageClass(types.StorageClassStandardIa), WithEncryptionContext("key-id", nil), WithCreateOnly())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	marker := fake.putInputs[len(fake.putInputs)-1]
+	if got := aws.ToString(marker.Key); got != "events/_year=2024/_month=09/_day=30/_date=2024-09-30/_SUCCESS" {
+		t.Fatalf("actual marker key `%v` \n expected the _SUCCESS marker", got)
+	}
+
+	if marker.StorageClass != types.StorageClassStandardIa {
+		t.Errorf("actual storage class `%v` \n expected `%v`", marker.StorageClass, types.StorageClassStandardIa)
+	}
+
+	if got := aws.ToString(marker.SSEKMSKeyId); got != "key-id" {
+		t.Errorf("actual KMS key ID `%v` \n expected `key-id`", got)
+	}
+
+	if marker.IfNoneMatch != nil {
+		t.Errorf("actual If-None-Match `%v` \n expected none", aws.ToString(marker.IfNoneMatch))
+	}
+}
+
+func TestClient_UploadFileBase_atomicUpload(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json",
+		WithAtomicUpload(), WithStorageClass(types.StorageClassStandardIa), VerifyAfterUpload())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.putInputs) != 1 {
+		t.Fatalf("actual %d uploads \n expected 1", len(fake.putInputs))
+	}
+
+	tempKey := aws.ToString(fake.putInputs[0].Key)
+	if !strings.HasPrefix(tempKey, "raw/.data.json.") || !strings.HasSuffix(tempKey, ".tmp") {
+		t.Errorf("actual temporary key `%v` \n expected `raw/.data.json.*.tmp`", tempKey)
+	}
+
+	if _, ok := fake.get("bucket", tempKey); ok {
+		t.Errorf("temporary object `%v` was not deleted", tempKey)
+	}
+
+	object, ok := fake.get("bucket", "raw/data.json")
+	if !ok {
+		t.Fatal("object `raw/data.json` was not published")
+	}
+
+	if string(object.body) != `{"a":1}` || object.contentType != "application/json" {
+		t.Errorf("actual `%s` of type `%v` \n expected `{\"a\":1}` of type `application/json`", object.body, object.contentType)
+	}
+
+	if len(fake.copyInputs) != 1 || fake.copyInputs[0].StorageClass != types.StorageClassStandardIa {
+		t.Errorf("actual copies `%v` \n expected one copy with storage class `%v`", fake.copyInputs, types.StorageClassStandardIa)
+	}
+}
+
+func Test_validateObjectKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			key:     "raw/2024/data file (1).json",
+			wantErr: false,
+		},
+		{
+			name:    "unicode",
+			key:     "raw/данные.json",
+			wantErr: false,
+		},
+		{
+			name:    "max_length",
+			key:     strings.Repeat("a", 1024),
+			wantErr: false,
+		},
+		{
+			name:    "empty",
+			key:     "",
+			wantErr: true,
+		},
+		{
+			name:    "too_long",
+			key:     strings.Repeat("a", 1025),
+			wantErr: true,
+		},
+		{
+			name:    "newline",
+			key:     "raw/data\n.json",
+			wantErr: true,
+		},
+		{
+			name:    "nul",
+			key:     "raw/data\x00.json",
+			wantErr: true,
+		},
+		{
+			name:    "delete",
+			key:     "raw/data\x7f.json",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateObjectKey(tt.key)
+
+			var validationErr ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateRelativePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		wantErr  bool
+	}{
+		{
+			name:     "file_name",
+			filePath: "data.csv",
+			wantErr:  false,
+		},
+		{
+			name:     "nested",
+			filePath: "region/us/data.csv",
+			wantErr:  false,
+		},
+		{
+			name:     "dot_prefix",
+			filePath: "./region/data.csv",
+			wantErr:  false,
+		},
+		{
+			name:     "windows_nested",
+			filePath: `region\us\data.csv`,
+			wantErr:  false,
+		},
+		{
+			name:     "inner_parent",
+			filePath: "region/../data.csv",
+			wantErr:  false,
+		},
+		{
+			name:     "absolute",
+			filePath: "/tmp/data.csv",
+			wantErr:  true,
+		},
+		{
+			name:     "windows_absolute",
+			filePath: `C:\export\data.csv`,
+			wantErr:  true,
+		},
+		{
+			name:     "parent",
+			filePath: "../data.csv",
+			wantErr:  true,
+		},
+		{
+			name:     "windows_parent",
+			filePath: `region\..\..\data.csv`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRelativePath(tt.filePath)
+
+			var validationErr ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected error `%v`", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_UploadFileBase_invalidKey(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", writeTestFile(t, "test.json", `{"a":1}`), "data\t.json")
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	if len(fake.putInputs) != 0 {
+		t.Errorf("actual %d uploads \n expected none", len(fake.putInputs))
+	}
+}
+
+func TestClient_UploadFileBase_allowEmpty(t *testing.T) {
+	filePath := writeTestFile(t, ".keep", "")
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, ".keep")
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+
+	_, err = client.UploadFileBase(context.Background(), "bucket", "raw", filePath, ".keep", WithAllowEmpty())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	object, ok := fake.get("bucket", "raw/.keep")
+	if !ok || len(object.body) != 0 {
+		t.Errorf("actual `%v` `%v` \n expected an empty object `raw/.keep`", object.body, ok)
+	}
+}
+
+func TestClient_Upload_acl(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "test.json", WithACL(types.ObjectCannedACLBucketOwnerFullControl))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if acl := fake.putInputs[0].ACL; acl != types.ObjectCannedACLBucketOwnerFullControl {
+		t.Errorf("actual `%v` \n expected `%v`", acl, types.ObjectCannedACLBucketOwnerFullControl)
+	}
+
+	_, err = client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "test.json", WithACL("public"))
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_Upload_info(t *testing.T) {
+	filePath := writeTestFile(t, "report.csv", "id\n1\n")
+	date := time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		upload  func(client *Client) (UploadInfo, error)
+		wantKey string
+	}{
+		{
+			name: "date_destination",
+			upload: func(client *Client) (UploadInfo, error) {
+				return client.UploadFileWithDateDestination(context.Background(), "bucket", "reports", filePath, date)
+			},
+			wantKey: "reports/_year=2024/_month=03/_day=07/_date=2024-03-07/report.csv",
+		},
+		{
+			name: "gzip",
+			upload: func(client *Client) (UploadInfo, error) {
+				return client.UploadFileBase(context.Background(), "bucket", "reports", filePath, "report.csv", WithGzip())
+			},
+			wantKey: "reports/report.csv.gz",
+		},
+		{
+			name: "atomic",
+			upload: func(client *Client) (UploadInfo, error) {
+				return client.UploadReader(context.Background(), "bucket", "reports", "report.csv", strings.NewReader("id\n1\n"), WithAtomicUpload())
+			},
+			wantKey: "reports/report.csv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			info, err := tt.upload(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if info.Key != tt.wantKey {
+				t.Errorf("actual `%v` \n expected `%v`", info.Key, tt.wantKey)
+			}
+
+			object, ok := fake.get("bucket", tt.wantKey)
+			if !ok {
+				t.Fatalf("object `%v` was not uploaded", tt.wantKey)
+			}
+
+			if info.ETag != object.etag {
+				t.Errorf("actual `%v` \n expected `%v`", info.ETag, object.etag)
+			}
+
+			if info.Size != int64(len(object.body)) {
+				t.Errorf("actual size `%v` \n expected `%v`", info.Size, len(object.body))
+			}
+
+			if info.Duration <= 0 {
+				t.Errorf("actual duration `%v` \n expected a positive duration", info.Duration)
+			}
+		})
+	}
+}
+
+func TestClient_Upload_checksumAlgorithm(t *testing.T) {
+	filePath := writeTestFile(t, "test.json", `{"a":1}`)
+
+	sum := sha256.Sum256([]byte(`{"a":1}`))
+	want := base64.StdEncoding.EncodeToString(sum[:])
+
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	info, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "test.json", WithChecksumAlgorithm(types.ChecksumAlgorithmSha256))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.ChecksumAlgorithm != types.ChecksumAlgorithmSha256 || info.Checksum != want {
+		t.Errorf("actual `%v %v` \n expected `%v %v`", info.ChecksumAlgorithm, info.Checksum, types.ChecksumAlgorithmSha256, want)
+	}
+
+	info, err = client.UploadReader(context.Background(), "bucket", "raw", "stream.json", strings.NewReader(`{"a":1}`), WithChecksumAlgorithm(types.ChecksumAlgorithmSha256))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.ChecksumAlgorithm != types.ChecksumAlgorithmSha256 || info.Checksum != want {
+		t.Errorf("actual `%v %v` \n expected `%v %v`", info.ChecksumAlgorithm, info.Checksum, types.ChecksumAlgorithmSha256, want)
+	}
+
+	for _, input := range fake.putInputs {
+		if input.ChecksumAlgorithm != types.ChecksumAlgorithmSha256 {
+			t.Errorf("actual `%v` \n expected `%v`", input.ChecksumAlgorithm, types.ChecksumAlgorithmSha256)
+		}
+	}
+
+	// The checksum of an atomic upload is the one of the copy, which is computed with the same algorithm.
+	info, err = client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "atomic.json", WithChecksumAlgorithm(types.ChecksumAlgorithmSha256), WithAtomicUpload())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(fake.copyInputs) != 1 || fake.copyInputs[0].ChecksumAlgorithm != types.ChecksumAlgorithmSha256 {
+		t.Errorf("actual copies `%v` \n expected one copy with checksum algorithm `%v`", fake.copyInputs, types.ChecksumAlgorithmSha256)
+	}
+
+	if object, _ := fake.get("bucket", "raw/atomic.json"); info.Checksum != want || object.checksumSHA256 != want {
+		t.Errorf("actual `%v %v` \n expected `%v %v`", info.Checksum, object.checksumSHA256, want, want)
+	}
+
+	_, err = client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "test.json", WithChecksumAlgorithm("MD4"))
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_Upload_conditional(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "locks/job.lock", []byte("owner-1"))
+
+	object, _ := fake.get("bucket", "locks/job.lock")
+
+	client := newTestClient(t, fake)
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    []UploadOption
+		wantErr error
+	}{
+		{
+			name: "create_only_absent",
+			key:  "new.lock",
+			opts: []UploadOption{WithCreateOnly()},
+		},
+		{
+			name:    "create_only_present",
+			key:     "job.lock",
+			opts:    []UploadOption{WithCreateOnly()},
+			wantErr: ErrPreconditionFailed,
+		},
+		{
+			name:    "if_match_stale",
+			key:     "job.lock",
+			opts:    []UploadOption{WithIfMatch(`"stale"`)},
+			wantErr: ErrPreconditionFailed,
+		},
+		{
+			name: "if_match_current",
+			key:  "job.lock",
+			opts: []UploadOption{WithIfMatch(object.etag)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.UploadReader(context.Background(), "bucket", "locks", tt.key, strings.NewReader("owner-2"), tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("actual error `%v` \n expected `%v`", err, tt.wantErr)
+			}
+		})
+	}
+
+	var validationErr ValidationError
+
+	_, err := client.UploadReader(context.Background(), "bucket", "locks", "job.lock", strings.NewReader("owner-2"), WithCreateOnly(), WithAtomicUpload())
+	if !errors.As(err, &validationErr) {
+		t.Errorf("actual error `%v` \n expected ValidationError", err)
+	}
+}
+
+func TestClient_Upload_objectExists(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/data.json", []byte(`{"a":1}`))
+
+	client := newTestClient(t, fake)
+
+	large := bytes.Repeat([]byte("0123456789abcdef"), int(manager.DefaultUploadPartSize/16+1))
+
+	tests := []struct {
+		name    string
+		upload  func() error
+		wantErr bool
+	}{
+		{
+			name: "file_present",
+			upload: func() error {
+				_, err := client.UploadFileBase(context.Background(), "bucket", "raw", writeTestFile(t, "data.json", `{"a":2}`), "data.json", WithCreateOnly())
+
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "stream_present",
+			upload: func() error {
+				_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{"a":2}`), WithCreateOnly())
+
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "multipart_stream_present",
+			upload: func() error {
+				_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", bytes.NewReader(large), WithCreateOnly())
+
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "stream_absent",
+			upload: func() error {
+				_, err := client.UploadReader(context.Background(), "bucket", "raw", "new.json", strings.NewReader(`{"a":2}`), WithCreateOnly())
+
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.upload()
+			if tt.wantErr != errors.Is(err, ErrObjectExists) || tt.wantErr != errors.Is(err, ErrPreconditionFailed) {
+				t.Errorf("actual error `%v` \n expected ErrObjectExists `%v`", err, tt.wantErr)
+			}
+		})
+	}
+
+	object, _ := fake.get("bucket", "raw/data.json")
+	if string(object.body) != `{"a":1}` {
+		t.Errorf("actual `%s` \n expected `{\"a\":1}`", object.body)
+	}
+}
+
+// cancellingReader serves size zero bytes and cancels the upload once after bytes are read.
+type cancellingReader struct {
+	size   int64
+	after  int64
+	read   int64
+	cancel context.CancelFunc
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	if r.read >= r.size {
+		return 0, io.EOF
+	}
+
+	n := int(min(int64(len(p)), r.size-r.read))
+	clear(p[:n])
+	r.read += int64(n)
+
+	if r.read >= r.after {
+		r.cancel()
+	}
+
+	return n, nil
+}
+
+func TestClient_UploadReader_contentLength(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), int(manager.DefaultUploadPartSize/16+1))
+
+	tests := []struct {
+		name              string
+		body              func() io.Reader
+		opts              []UploadOption
+		wantContentLength *int64
+		wantMultipart     bool
+	}{
+		{
+			name:              "sized",
+			body:              func() io.Reader { return bytes.NewReader(content) },
+			opts:              []UploadOption{WithContentLength(int64(len(content)))},
+			wantContentLength: aws.Int64(int64(len(content))),
+		},
+		{
+			name: "unsized_pipe",
+			body: func() io.Reader {
+				pipeReader, pipeWriter := io.Pipe()
+
+				go func() {
+					_, err := pipeWriter.Write(content)
+					pipeWriter.CloseWithError(err)
+				}()
+
+				return pipeReader
+			},
+			wantMultipart: true,
+		},
+		{
+			name: "sized_pipe",
+			body: func() io.Reader {
+				pipeReader, pipeWriter := io.Pipe()
+
+				go func() {
+					_, err := pipeWriter.Write(content)
+					pipeWriter.CloseWithError(err)
+				}()
+
+				return pipeReader
+			},
+			opts:          []UploadOption{WithContentLength(int64(len(content)))},
+			wantMultipart: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			info, err := client.UploadReader(context.Background(), "bucket", "raw", "data.bin", tt.body(), tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			object, ok := fake.get("bucket", "raw/data.bin")
+			if !ok {
+				t.Fatal("object was not uploaded")
+			}
+
+			if !bytes.Equal(object.body, content) {
+				t.Errorf("actual size `%v` \n expected `%v`", len(object.body), len(content))
+			}
+
+			if info.Size != int64(len(content)) || info.ETag != object.etag {
+				t.Errorf("actual `%v %v` \n expected `%v %v`", info.Size, info.ETag, len(content), object.etag)
+			}
+
+			if multipart := fake.multipartUploads != nil; multipart != tt.wantMultipart {
+				t.Errorf("actual multipart `%v` \n expected `%v`", multipart, tt.wantMultipart)
+			}
+
+			if !tt.wantMultipart && !reflect.DeepEqual(fake.putInputs[0].ContentLength, tt.wantContentLength) {
+				t.Errorf("actual `%v` \n expected `%v`", aws.ToInt64(fake.putInputs[0].ContentLength), aws.ToInt64(tt.wantContentLength))
+			}
+		})
+	}
+
+	invalid := [][]UploadOption{
+		{WithContentLength(-1)},
+		{WithContentLength(10), WithGzip()},
+	}
+
+	for _, opts := range invalid {
+		_, err := newTestClient(t, newFakeS3()).UploadReader(context.Background(), "bucket", "raw", "data.bin", strings.NewReader("data"), opts...)
+
+		var validationErr ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("actual error `%v` \n expected ValidationError", err)
+		}
+	}
+}
+
+func TestClient_UploadReader_contentLengthRetry(t *testing.T) {
+	const internalErrorBody = `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>InternalError</Code><Message>We encountered an internal error. Please try again.</Message></Error>`
+
+	httpClient := &fakeHTTPClient{
+		responses: []fakeHTTPResponse{
+			{status: http.StatusInternalServerError, body: internalErrorBody},
+			{status: http.StatusOK},
+		},
+	}
+
+	o, err := newClientOptions([]Option{WithRetry(2, time.Millisecond)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newHTTPTestClient(t, httpClient, o.retryer())
+
+	content := []byte(`{"a":1}`)
+
+	info, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", bytes.NewReader(content), WithContentLength(int64(len(content))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(httpClient.requests) != 2 {
+		t.Fatalf("actual `%v` requests \n expected `2`", len(httpClient.requests))
+	}
+
+	for _, req := range httpClient.requests {
+		if req.Method != http.MethodPut || req.ContentLength != int64(len(content)) {
+			t.Errorf("actual `%v %v` \n expected a PutObject request of `%v` bytes", req.Method, req.ContentLength, len(content))
+		}
+	}
+
+	if info.Size != int64(len(content)) {
+		t.Errorf("actual `%v` \n expected `%v`", info.Size, len(content))
+	}
+}
+
+func TestUploadOption_unsupported(t *testing.T) {
+	filePath := writeTestFile(t, "data.json", `{"a":1}`)
+	date := time.Date(2024, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		upload func(client *Client) error
+	}{
+		{
+			name: "file_base_content_length",
+			upload: func(client *Client) error {
+				_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json", WithContentLength(7))
+
+				return err
+			},
+		},
+		{
+			name: "date_destination_content_length",
+			upload: func(client *Client) error {
+				_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "raw", filePath, date, WithContentLength(7))
+
+				return err
+			},
+		},
+		{
+			name: "date_destination_preserve_slashes",
+			upload: func(client *Client) error {
+				_, err := client.UploadFileWithDateDestination(context.Background(), "bucket", "/raw/", filePath, date, PreserveSlashes())
+
+				return err
+			},
+		},
+		{
+			name: "file_base_relative_path",
+			upload: func(client *Client) error {
+				_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json", WithRelativePath())
+
+				return err
+			},
+		},
+		{
+			name: "reader_relative_path",
+			upload: func(client *Client) error {
+				_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{"a":1}`), WithRelativePath())
+
+				return err
+			},
+		},
+		{
+			name: "json_content_length",
+			upload: func(client *Client) error {
+				_, err := client.UploadJSON(context.Background(), "bucket", "raw", "data.json", map[string]int{"a": 1}, WithContentLength(7))
+
+				return err
+			},
+		},
+		{
+			name: "json_relative_path",
+			upload: func(client *Client) error {
+				_, err := client.UploadJSON(context.Background(), "bucket", "raw", "data.json", map[string]int{"a": 1}, WithRelativePath())
+
+				return err
+			},
+		},
+		{
+			name: "file_base_partition_marker",
+			upload: func(client *Client) error {
+				_, err := client.UploadFileBase(context.Background(), "bucket", "raw", filePath, "data.json", WithPartitionMarker(""))
+
+				return err
+			},
+		},
+		{
+			name: "reader_partition_marker",
+			upload: func(client *Client) error {
+				_, err := client.UploadReader(context.Background(), "bucket", "raw", "data.json", strings.NewReader(`{"a":1}`), WithPartitionMarker(""))
+
+				return err
+			},
+		},
+		{
+			name: "json_partition_marker",
+			upload: func(client *Client) error {
+				_, err := client.UploadJSON(context.Background(), "bucket", "raw", "data.json", map[string]int{"a": 1}, WithPartitionMarker(""))
+
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeS3()
+			client := newTestClient(t, fake)
+
+			err := tt.upload(client)
+
+			var validationErr ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("actual error `%v` \n expected ValidationError", err)
+			}
+
+			if len(fake.putInputs) != 0 {
+				t.Errorf("actual `%d` requests \n expected no request", len(fake.putInputs))
+			}
+		})
+	}
+}
+
+func TestClient_UploadReader_abortOnCancel(t *testing.T) {
+	fake := newFakeS3()
+	client := newTestClient(t, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := &cancellingReader{size: 3 * manager.DefaultUploadPartSize, after: manager.DefaultUploadPartSize + 1, cancel: cancel}
+
+	_, err := client.UploadReader(ctx, "bucket", "raw", "large.bin", body)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("actual error `%v` \n expected `%v`", err, context.Canceled)
+	}
+
+	if fake.multipartUploads["bucket"] == nil {
+		t.Fatal("expected a multipart upload")
+	}
+
+	if len(fake.multipartUploads["bucket"]) != 0 {
+		t.Errorf("actual incomplete uploads `%v` \n expected none", fake.multipartUploads["bucket"])
+	}
+}
+
+func Test_uploadOptions_objectKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []UploadOption
+		directory string
+		want      string
+	}{
+		{
+			name:      "trimmed",
+			directory: "/raw/",
+			want:      "raw/data.json",
+		},
+		{
+			name:      "preserved_slashes",
+			opts:      []UploadOption{PreserveSlashes()},
+			directory: "/raw/",
+			want:      "/raw/data.json",
+		},
+		{
+			name:      "gzip",
+			opts:      []UploadOption{WithGzip(), PreserveSlashes()},
+			directory: "raw",
+			want:      "raw/data.json.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := newUploadOptions(tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := o.objectKey(tt.directory, "data.json"); got != tt.want {
+				t.Errorf("actual `%v` \n expected `%v`", got, tt.want)
+			}
+		})
+	}
+}
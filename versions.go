@@ -0,0 +1,222 @@
+package s3utils
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectVersion describes a version of an object in a versioned bucket.
+type ObjectVersion struct {
+	Key          string    `json:"key"`
+	VersionID    string    `json:"version_id"`
+	IsLatest     bool      `json:"is_latest"`
+	LastModified time.Time `json:"last_modified"`
+	// IsDeleteMarker is set for delete markers, which have no content.
+	IsDeleteMarker bool  `json:"is_delete_marker,omitempty"`
+	Size           int64 `json:"size"`
+}
+
+// ListObjectVersions returns all versions and delete markers of the objects under the prefix.
+func (s *Client) ListObjectVersions(ctx context.Context, bucketName string, prefix string) ([]ObjectVersion, error) {
+	if bucketName == "" {
+		return nil, NewValidationError("bucket name is empty")
+	}
+
+	var versions []ObjectVersion
+
+	err := s.walkObjectVersions(ctx, bucketName, prefix, func(version ObjectVersion) error {
+		versions = append(versions, version)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// DeleteObjectVersion permanently deletes a specific version of an object.
+func (s *Client) DeleteObjectVersion(ctx context.Context, bucketName string, key string, versionID string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	if key == "" {
+		return NewValidationError("key is empty")
+	}
+
+	if versionID == "" {
+		return NewValidationError("version id is empty")
+	}
+
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(bucketName),
+		Key:       aws.String(key),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return NewS3Error("unable to delete object version", err)
+	}
+
+	return nil
+}
+
+// EmptyBucket permanently deletes all objects of the bucket, including all their versions and delete markers,
+// e.g. before the bucket is deleted. Versions are deleted in batches of 1000 while they are listed.
+// Versions that S3 failed to delete are reported by a MultiDeleteError wrapped in the returned error.
+func (s *Client) EmptyBucket(ctx context.Context, bucketName string) error {
+	if bucketName == "" {
+		return NewValidationError("bucket name is empty")
+	}
+
+	ctx = s.startRetryBudget(ctx)
+
+	var (
+		batch []types.ObjectIdentifier
+		errs  []DeleteObjectError
+	)
+
+	flush := func() error {
+		failed, err := s.deleteVersions(ctx, bucketName, batch)
+		errs = append(errs, failed...)
+		batch = nil
+
+		return err
+	}
+
+	err := s.walkObjectVersions(ctx, bucketName, "", func(version ObjectVersion) error {
+		batch = append(batch, types.ObjectIdentifier{
+			Key:       aws.String(version.Key),
+			VersionId: aws.String(version.VersionID),
+		})
+
+		if len(batch) < maxDeleteObjects {
+			return nil
+		}
+
+		return flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return NewS3Error("unable to delete some object versions", NewMultiDeleteError(errs))
+	}
+
+	return nil
+}
+
+// deleteVersions deletes the object versions with a single request and returns the per-version errors reported by S3.
+func (s *Client) deleteVersions(ctx context.Context, bucketName string, versions []types.ObjectIdentifier) ([]DeleteObjectError, error) {
+	deleteResp, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(bucketName),
+		Delete: &types.Delete{
+			Objects: versions,
+			Quiet:   aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return nil, NewS3Error("unable to delete object versions", err)
+	}
+
+	errs := make([]DeleteObjectError, 0, len(deleteResp.Errors))
+	for _, deleteErr := range deleteResp.Errors {
+		errs = append(errs, DeleteObjectError{
+			Key:     aws.ToString(deleteErr.Key),
+			Code:    aws.ToString(deleteErr.Code),
+			Message: aws.ToString(deleteErr.Message),
+		})
+	}
+
+	return errs, nil
+}
+
+// hardDeleteObject deletes all versions and delete markers of the key, so no trace of the object is left.
+// If the key has no versions, e.g. in an unversioned bucket, the object is deleted normally.
+func (s *Client) hardDeleteObject(ctx context.Context, bucketName string, key string) error {
+	var versionIDs []string
+
+	err := s.walkObjectVersions(ctx, bucketName, key, func(version ObjectVersion) error {
+		if version.Key == key {
+			versionIDs = append(versionIDs, version.VersionID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(versionIDs) == 0 {
+		return s.DeleteObject(ctx, bucketName, key)
+	}
+
+	for _, versionID := range versionIDs {
+		err := s.DeleteObjectVersion(ctx, bucketName, key, versionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkObjectVersions calls fn for every version and delete marker under the prefix, paginating through the listing.
+func (s *Client) walkObjectVersions(ctx context.Context, bucketName string, prefix string, fn func(version ObjectVersion) error) error {
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	}
+
+	for {
+		page, err := s.client.ListObjectVersions(ctx, input)
+		if err != nil {
+			return NewS3Error("unable to list object versions", err)
+		}
+
+		for _, version := range page.Versions {
+			err = fn(ObjectVersion{
+				Key:          aws.ToString(version.Key),
+				VersionID:    aws.ToString(version.VersionId),
+				IsLatest:     aws.ToBool(version.IsLatest),
+				LastModified: aws.ToTime(version.LastModified),
+				Size:         aws.ToInt64(version.Size),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, marker := range page.DeleteMarkers {
+			err = fn(ObjectVersion{
+				Key:            aws.ToString(marker.Key),
+				VersionID:      aws.ToString(marker.VersionId),
+				IsLatest:       aws.ToBool(marker.IsLatest),
+				LastModified:   aws.ToTime(marker.LastModified),
+				IsDeleteMarker: true,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			return nil
+		}
+
+		input.KeyMarker = page.NextKeyMarker
+		input.VersionIdMarker = page.NextVersionIdMarker
+	}
+}
@@ -0,0 +1,147 @@
+package s3utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClient_ListObjectVersions(t *testing.T) {
+	fake := newFakeS3()
+	fake.versionPageSize = 2
+	fake.addVersions("bucket", "raw/a.json", "a1", "a2", "a3")
+	fake.addVersions("bucket", "raw/b.json", "b1")
+	fake.addVersions("bucket", "other/c.json", "c1")
+
+	client := newTestClient(t, fake)
+
+	versions, err := client.ListObjectVersions(context.Background(), "bucket", "raw/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []ObjectVersion{
+		{Key: "raw/a.json", VersionID: "a1"},
+		{Key: "raw/a.json", VersionID: "a2"},
+		{Key: "raw/a.json", VersionID: "a3", IsLatest: true},
+		{Key: "raw/b.json", VersionID: "b1", IsLatest: true},
+	}
+
+	if len(versions) != len(want) {
+		t.Fatalf("actual `%v` \n expected `%v`", versions, want)
+	}
+
+	for i := range want {
+		if versions[i] != want[i] {
+			t.Errorf("version %d: actual `%v` \n expected `%v`", i, versions[i], want[i])
+		}
+	}
+}
+
+func TestClient_DeleteObjectVersion(t *testing.T) {
+	fake := newFakeS3()
+	fake.addVersions("bucket", "raw/a.json", "a1", "a2")
+
+	client := newTestClient(t, fake)
+
+	err := client.DeleteObjectVersion(context.Background(), "bucket", "raw/a.json", "a1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versions, err := client.ListObjectVersions(context.Background(), "bucket", "raw/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(versions) != 1 || versions[0].VersionID != "a2" {
+		t.Errorf("actual `%v` \n expected the single version `a2`", versions)
+	}
+}
+
+func TestClient_DeleteObject_hardDelete(t *testing.T) {
+	fake := newFakeS3()
+	fake.put("bucket", "raw/a.json", []byte("1"))
+	fake.addVersions("bucket", "raw/a.json", "a1", "a2", "a3")
+	fake.addVersions("bucket", "raw/a.json.bak", "b1")
+
+	client := newTestClient(t, fake)
+
+	err := client.DeleteObject(context.Background(), "bucket", "raw/a.json", HardDelete())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versions, err := client.ListObjectVersions(context.Background(), "bucket", "raw/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(versions) != 1 || versions[0].Key != "raw/a.json.bak" {
+		t.Errorf("actual `%v` \n expected only the versions of `raw/a.json.bak`", versions)
+	}
+
+	// Without versions the object is deleted normally.
+	fake.put("bucket", "raw/b.json", []byte("1"))
+
+	err = client.DeleteObject(context.Background(), "bucket", "raw/b.json", HardDelete())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := fake.get("bucket", "raw/b.json"); ok {
+		t.Error("object was not deleted")
+	}
+}
+
+func TestClient_EmptyBucket(t *testing.T) {
+	fake := newFakeS3()
+	fake.versionPageSize = 100
+
+	for i := range 1200 {
+		fake.addVersions("bucket", fmt.Sprintf("raw/%04d.json", i), "v1", "v2")
+	}
+
+	fake.addDeleteMarkers("bucket", "raw/deleted.json", "m1")
+
+	client := newTestClient(t, fake)
+
+	if err := client.EmptyBucket(context.Background(), "bucket"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	versions, err := client.ListObjectVersions(context.Background(), "bucket", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(versions) != 0 {
+		t.Errorf("actual %d versions \n expected none", len(versions))
+	}
+
+	// 2401 versions and delete markers are deleted in batches of 1000.
+	if len(fake.deleteInputs) != 3 {
+		t.Errorf("actual %d DeleteObjects requests \n expected 3", len(fake.deleteInputs))
+	}
+}
+
+func TestClient_EmptyBucket_partialFailure(t *testing.T) {
+	fake := newFakeS3()
+	fake.addVersions("bucket", "raw/a.json", "a1")
+	fake.addVersions("bucket", "raw/locked.json", "l1")
+	fake.lockedKeys = map[string]bool{"raw/locked.json": true}
+
+	client := newTestClient(t, fake)
+
+	err := client.EmptyBucket(context.Background(), "bucket")
+
+	var multiErr MultiDeleteError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("actual error `%v` \n expected MultiDeleteError", err)
+	}
+
+	if len(multiErr.Errors) != 1 || multiErr.Errors[0].Key != "raw/locked.json" {
+		t.Errorf("actual `%v` \n expected an error for `raw/locked.json`", multiErr.Errors)
+	}
+}